@@ -0,0 +1,111 @@
+package getuipush
+
+import (
+	"errors"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+// AudienceBuilder 按标签组合推送目标
+//
+//	不同条件之间是交集的关系，同一条件内的values是并集的关系
+//	tags, err := NewAudienceBuilder().ClientTypes(Android, Harmony).CustomTags("vip").Regions("11000000").Tags()
+type AudienceBuilder struct {
+	tags []*models.Tag
+	err  error
+}
+
+// NewAudienceBuilder 返回一个空的 AudienceBuilder
+func NewAudienceBuilder() *AudienceBuilder {
+	return &AudienceBuilder{
+		tags: make([]*models.Tag, 0),
+	}
+}
+
+// ClientTypes 按客户端类型筛选
+//
+//	可选多种客户端类型，如 Android+Harmony
+func (b *AudienceBuilder) ClientTypes(clientTypes ...ClientType) *AudienceBuilder {
+	if len(clientTypes) == 0 {
+		b.setErr(errors.New("客户端类型为空"))
+		return b
+	}
+	phones := make([]string, 0, len(clientTypes))
+	seen := make(map[string]bool, len(clientTypes))
+	for _, clientType := range clientTypes {
+		phoneType, err := clientType.PhoneType()
+		if err != nil {
+			b.setErr(err)
+			return b
+		}
+		if seen[phoneType] {
+			continue
+		}
+		seen[phoneType] = true
+		phones = append(phones, phoneType)
+	}
+	return b.Tag(models.TagPhoneType(phones...))
+}
+
+// CustomTags 按自定义标签筛选
+//
+//	用户绑定了其中任意一个标签即可
+func (b *AudienceBuilder) CustomTags(customTags ...string) *AudienceBuilder {
+	if len(customTags) == 0 {
+		b.setErr(errors.New("自定义标签长度为0"))
+		return b
+	}
+	return b.Tag(models.TagCustom(customTags...))
+}
+
+// Regions 按地区筛选
+//
+//	regions 为个推的地区编号，如 11000000 表示北京
+func (b *AudienceBuilder) Regions(regions ...string) *AudienceBuilder {
+	if len(regions) == 0 {
+		b.setErr(errors.New("地区为空"))
+		return b
+	}
+	return b.Tag(models.TagRegion(regions...))
+}
+
+// Tag 添加一个自定义的标签表达式
+//
+//	如 models.TagRegion("11000000").Not()
+func (b *AudienceBuilder) Tag(tag *models.Tag) *AudienceBuilder {
+	if err := tag.Validate(); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tags = append(b.tags, tag)
+	return b
+}
+
+// Tags 返回标签表达式
+//
+//	可用于 PushAllByLogicTags 和 GetUserCount
+func (b *AudienceBuilder) Tags() ([]*models.Tag, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.tags) == 0 {
+		return nil, errors.New("标签表达式长度为0")
+	}
+	return b.tags, nil
+}
+
+// Build 返回推送目标
+func (b *AudienceBuilder) Build() (*models.Audience, error) {
+	tags, err := b.Tags()
+	if err != nil {
+		return nil, err
+	}
+	return models.ByTags(tags...), nil
+}
+
+// setErr 记录第一个错误
+func (b *AudienceBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,155 @@
+package getuipush_test
+
+import (
+	"reflect"
+	"testing"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestAudienceBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder
+		want    []*models.Tag
+		wantErr bool
+	}{
+		{
+			name:  "one client type",
+			build: func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder { return b.ClientTypes(getuipush.IOS) },
+			want:  []*models.Tag{models.TagPhoneType("ios")},
+		},
+		{
+			name: "several client types",
+			build: func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder {
+				return b.ClientTypes(getuipush.Android, getuipush.Harmony)
+			},
+			want: []*models.Tag{models.TagPhoneType("android", "harmony")},
+		},
+		{
+			name: "duplicate client types",
+			build: func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder {
+				return b.ClientTypes(getuipush.Android, getuipush.Android, getuipush.IOS)
+			},
+			want: []*models.Tag{models.TagPhoneType("android", "ios")},
+		},
+		{
+			name: "combined conditions",
+			build: func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder {
+				return b.ClientTypes(getuipush.Android, getuipush.Harmony).
+					CustomTags("vip", "gk2025").
+					Regions("11000000").
+					Tag(models.TagRegion("12000000").Not())
+			},
+			want: []*models.Tag{
+				models.TagPhoneType("android", "harmony"),
+				models.TagCustom("vip", "gk2025"),
+				models.TagRegion("11000000"),
+				models.TagRegion("12000000").Not(),
+			},
+		},
+		{
+			name:    "empty builder",
+			build:   func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder { return b },
+			wantErr: true,
+		},
+		{
+			name:    "no client types",
+			build:   func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder { return b.ClientTypes() },
+			wantErr: true,
+		},
+		{
+			name: "wechat app",
+			build: func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder {
+				return b.ClientTypes(getuipush.Android, getuipush.WechatAPP)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown client type",
+			build:   func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder { return b.ClientTypes(99) },
+			wantErr: true,
+		},
+		{
+			name:    "no custom tags",
+			build:   func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder { return b.CustomTags() },
+			wantErr: true,
+		},
+		{
+			name:    "no regions",
+			build:   func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder { return b.Regions() },
+			wantErr: true,
+		},
+		{
+			name: "error is kept after valid conditions",
+			build: func(b *getuipush.AudienceBuilder) *getuipush.AudienceBuilder {
+				return b.Regions().ClientTypes(getuipush.IOS).CustomTags("vip")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := tt.build(getuipush.NewAudienceBuilder()).Tags()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Tags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tags, tt.want) {
+				t.Fatalf("Tags() = %+v, want %+v", tags, tt.want)
+			}
+			audience, err := tt.build(getuipush.NewAudienceBuilder()).Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(audience, models.ByTags(tt.want...)) {
+				t.Fatalf("Build() = %+v, want tags %+v", audience, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushAllByClients(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &models.CustomMessage{Title: "标题", Content: "内容"}
+
+	tests := []struct {
+		name        string
+		clientTypes []getuipush.ClientType
+		want        []string
+		wantErr     bool
+	}{
+		{name: "android and harmony", clientTypes: []getuipush.ClientType{getuipush.Android, getuipush.Harmony}, want: []string{"android", "harmony"}},
+		{name: "all", clientTypes: []getuipush.ClientType{getuipush.Android, getuipush.IOS, getuipush.Harmony}, want: []string{"android", "ios", "harmony"}},
+		{name: "none", wantErr: true},
+		{name: "with wechat app", clientTypes: []getuipush.ClientType{getuipush.IOS, getuipush.WechatAPP}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(s.Pushes())
+			_, err := client.PushAllByClients(int(getuipush.ArticleMsg), 0, tt.clientTypes, payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("PushAllByClients should fail")
+				}
+				if n := len(s.Pushes()) - before; n != 0 {
+					t.Fatalf("server received %d pushes, want 0", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []*models.Tag{models.TagPhoneType(tt.want...)}
+			if got := s.LastPush().Param.Audience.Tag; !reflect.DeepEqual(got, want) {
+				t.Fatalf("audience tags = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -1,6 +1,15 @@
 package getuipush
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 const (
+	// maxScheduleDuration 定时推送时间必须在7天之内
+	maxScheduleDuration = 7 * 24 * time.Hour
+
 	//APIURL 服务器地址
 	APIURL string = "https://restapi.getui.com/v2/"
 
@@ -13,8 +22,23 @@ const (
 	// PrivateChannel 聊天推送
 	PrivateChannel = 2
 
+	// reportDateLayout 统计接口的日期格式
+	reportDateLayout = "2006-01-02"
+
 	// limit 多个cid群推时，每次的用户量
 	limit = 1000
+
+	// aliasLimit 批量绑定/解绑别名时，每次的数量
+	aliasLimit = 1000
+
+	// tagCidLimit 一批用户绑定/解绑一个标签时，每次的用户量
+	tagCidLimit = 1000
+
+	// reportTaskLimit 查询推送数据时，每次最多的taskid数量
+	reportTaskLimit = 200
+
+	// reportMaxDays 按天查询统计数据时，一次最多的天数
+	reportMaxDays = 31
 )
 
 type MessageType int
@@ -265,5 +289,27 @@ type ClientType int
 const (
 	Android   ClientType = iota + 1 //android
 	IOS                             //ios
-	WechatAPP                       //微信小程序
+	WechatAPP                       //微信小程序，个推不支持推送
+	Harmony                         //鸿蒙
 )
+
+// 客户端类型对应的个推 phone_type 标签值
+var clientTypePhoneType = map[ClientType]string{
+	Android: "android",
+	IOS:     "ios",
+	Harmony: "harmony",
+}
+
+// PhoneType 返回客户端类型对应的个推 phone_type 标签值
+//
+//	微信小程序不是个推的推送目标，返回错误
+func (c ClientType) PhoneType() (string, error) {
+	if c == WechatAPP {
+		return "", errors.New("个推不支持推送到微信小程序，请使用微信订阅消息")
+	}
+	phoneType, ok := clientTypePhoneType[c]
+	if !ok {
+		return "", fmt.Errorf("不支持的客户端类型: %d", c)
+	}
+	return phoneType, nil
+}
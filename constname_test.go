@@ -0,0 +1,81 @@
+package getuipush_test
+
+import (
+	"testing"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestClientTypePhoneType(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientType getuipush.ClientType
+		want       string
+		wantErr    bool
+	}{
+		{name: "android", clientType: getuipush.Android, want: "android"},
+		{name: "ios", clientType: getuipush.IOS, want: "ios"},
+		{name: "harmony", clientType: getuipush.Harmony, want: "harmony"},
+		{name: "wechat app", clientType: getuipush.WechatAPP, wantErr: true},
+		{name: "zero", clientType: 0, wantErr: true},
+		{name: "unknown", clientType: 99, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.clientType.PhoneType()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PhoneType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("PhoneType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushAllByClient(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &models.CustomMessage{Title: "标题", Content: "内容"}
+
+	tests := []struct {
+		name       string
+		clientType getuipush.ClientType
+		want       string
+		wantErr    bool
+	}{
+		{name: "android", clientType: getuipush.Android, want: "android"},
+		{name: "ios", clientType: getuipush.IOS, want: "ios"},
+		{name: "harmony", clientType: getuipush.Harmony, want: "harmony"},
+		{name: "wechat app", clientType: getuipush.WechatAPP, wantErr: true},
+		{name: "unknown", clientType: 99, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(s.Pushes())
+			_, err := client.PushAllByClient(int(getuipush.ArticleMsg), 0, tt.clientType, payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("PushAllByClient should fail")
+				}
+				if n := len(s.Pushes()) - before; n != 0 {
+					t.Fatalf("server received %d pushes, want 0", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags := s.LastPush().Param.Audience.Tag
+			if len(tags) != 1 || tags[0].Key != "phone_type" || len(tags[0].Values) != 1 || tags[0].Values[0] != tt.want {
+				t.Fatalf("audience tags = %+v, want phone_type %s", tags, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package getuipush
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+const (
+	// dryRunTaskId 演练模式下返回的taskid
+	dryRunTaskId = "dry-run"
+)
+
+// DryRun 演练模式
+//
+//	构造并校验推送参数，但不发送推送请求
+//	设置了 SafetyLimit 时仍会请求预估推送范围，超过上限时返回 *SafetyLimitError；其他情况不获取token
+//	每个接口的请求参数按顺序记录，可用于审核推送内容
+type DryRun struct {
+	mu       sync.Mutex
+	requests []*DryRunRequest
+}
+
+// DryRunRequest 演练模式下记录的请求
+type DryRunRequest struct {
+	Method string          `json:"method"` //请求方法
+	Path   string          `json:"path"`   //接口路径，不含 APIURL，如 {appId}/push/all
+	Body   json.RawMessage `json:"body"`   //请求的json
+}
+
+// NewDryRun 返回演练模式的记录器
+func NewDryRun() *DryRun {
+	return &DryRun{
+		requests: make([]*DryRunRequest, 0),
+	}
+}
+
+// Requests 返回已记录的请求
+func (d *DryRun) Requests() []*DryRunRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]*DryRunRequest, len(d.requests))
+	copy(list, d.requests)
+	return list
+}
+
+// Last 返回最后一次记录的请求，没有时返回nil
+func (d *DryRun) Last() *DryRunRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.requests) == 0 {
+		return nil
+	}
+	return d.requests[len(d.requests)-1]
+}
+
+// Reset 清空已记录的请求
+func (d *DryRun) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = make([]*DryRunRequest, 0)
+}
+
+// record 记录请求，返回模拟的成功结果
+func (d *DryRun) record(method, path string, v interface{}) (*models.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.requests = append(d.requests, &DryRunRequest{
+		Method: method,
+		Path:   path,
+		Body:   body,
+	})
+	d.mu.Unlock()
+	return &models.Response{
+		Code:   0,
+		Msg:    "dry run",
+		Data:   `{"taskid":"` + dryRunTaskId + `"}`,
+		TaskId: dryRunTaskId,
+	}, nil
+}
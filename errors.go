@@ -0,0 +1,60 @@
+package getuipush
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 推送参数错误，可使用 errors.Is 判断
+var (
+	// ErrNilPayload 消息内容为nil
+	ErrNilPayload = errors.New(NAME + " 消息内容为nil")
+
+	// ErrEmptyPayload 消息内容为空
+	ErrEmptyPayload = errors.New(NAME + " 消息内容为空")
+
+	// ErrEmptyCid cid为空
+	ErrEmptyCid = errors.New(NAME + " cid为空")
+
+	// ErrEmptyAlias 别名为空
+	ErrEmptyAlias = errors.New(NAME + " 别名为空")
+
+	// ErrScheduleOption WithScheduleTime 只用于 PushMessage，其他方法请使用 scheduleTime 参数或 *At 方法
+	ErrScheduleOption = errors.New(NAME + " WithScheduleTime 只用于 PushMessage")
+
+	// ErrScheduleNotSupported 单推、voip和静默消息不支持定时推送
+	ErrScheduleNotSupported = errors.New(NAME + " 单推、voip和静默消息不支持定时推送")
+)
+
+// ScheduleTimeError 定时推送时间校验失败
+//
+//	定时推送时间必须是当前时间之后，7天之内的时间
+type ScheduleTimeError struct {
+	ScheduleTime time.Time //定时推送时间
+	Reason       string    //失败原因
+}
+
+func (e *ScheduleTimeError) Error() string {
+	return fmt.Sprintf("%s 定时推送时间 %s 无效: %s", NAME, e.ScheduleTime.Format("2006-01-02 15:04:05"), e.Reason)
+}
+
+// TagExprError 标签表达式语法错误
+type TagExprError struct {
+	Pos int    //出错的位置(字符)，从1开始
+	Msg string //错误信息
+}
+
+func (e *TagExprError) Error() string {
+	return fmt.Sprintf("%s 标签表达式第%d个字符附近: %s", NAME, e.Pos, e.Msg)
+}
+
+// SafetyLimitError 预估推送用户数超过上限，已拒绝推送
+type SafetyLimitError struct {
+	Count int64 //预估用户数
+	Limit int64 //上限
+}
+
+func (e *SafetyLimitError) Error() string {
+	return fmt.Sprintf("%s 预估推送用户数 %d 超过上限 %d，已拒绝推送", NAME, e.Count, e.Limit)
+}
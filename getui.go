@@ -16,8 +16,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/zituocn/logx"
+	"github.com/tidwall/gjson"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/zituocn/getui-push/models"
@@ -64,8 +65,19 @@ type HarmonyConfig struct {
 	Uri         string //鸿蒙配置
 }
 
+// AppConfig 应用相关配置
 type AppConfig struct {
-	Harmony *HarmonyConfig
+	Harmony       *HarmonyConfig
+	GroupNameFunc func() string   //默认任务组名的生成方法，为空时使用 ymzy_<year>
+	SafetyLimit   int64           //群推时预估用户数超过此值则拒绝推送，为0时不检查
+	DryRun        *DryRun         //演练模式，不为nil时只构造并校验推送参数，不发送请求
+	Logger        Logger          //日志，为空时使用 SetDefaultLogger 设置的日志，默认为logx
+	Tracer        Tracer          //链路追踪，可适配OpenTelemetry
+	Metrics       Metrics         //监控指标，可适配Prometheus或OpenTelemetry
+	Middlewares   []Middleware    //请求中间件，第一个在最外层，可用于审计、注入header、重试等
+	BaseURL       string          //个推API地址，为空时使用 APIURL，测试时可指向 getuitest.Server
+	TokenStore    TokenStore      //token的存储，为空时使用redis
+	Truncate      *TruncatePolicy //通知标题和内容的截断策略，为nil时不截断；单次推送可用 WithTruncate 覆盖或关闭
 }
 
 // PushClient 个推 push client
@@ -73,34 +85,40 @@ type PushClient struct {
 	*PushConfig
 	*PushStore
 	*AppConfig
+
+	debug int32 //是否为调试模式，1为调试模式，使用 SetDebug 修改
 }
 
 // NewPushClient 返回个推实例并初始化redis信息
+//
+//	toDebug 只打开当前client的调试模式，可使用 SetDebug 修改
+//	设置了 AppConfig.TokenStore 时，store可以为nil，不初始化redis
 func NewPushClient(conf *PushConfig, store *PushStore, app *AppConfig, toDebug bool) (client *PushClient, err error) {
 	if conf == nil {
 		err = errors.New("配置为空")
 		return
 	}
-	if store == nil {
-		err = errors.New("存储存储配置为空")
-		return
-	}
 	if conf.AppId == "" || conf.AppSecret == "" || conf.AppKey == "" {
 		err = errors.New("个推参数配置不完整")
 		return
 	}
-	if store.Host == "" || store.Port == 0 || store.DB < 0 {
-		err = errors.New("存储参数配置不完整")
-		return
-	}
-	if toDebug {
-		ToDebug = true
-	}
 	client = &PushClient{
 		PushConfig: conf,
 		PushStore:  store,
 		AppConfig:  app,
 	}
+	client.SetDebug(toDebug)
+	if app != nil && app.TokenStore != nil {
+		return
+	}
+	if store == nil {
+		err = errors.New("存储存储配置为空")
+		return
+	}
+	if store.Host == "" || store.Port == 0 || store.DB < 0 {
+		err = errors.New("存储参数配置不完整")
+		return
+	}
 	err = goredis.InitDefaultDB(&goredis.RedisConfig{
 		Host:     store.Host,
 		Port:     store.Port,
@@ -115,25 +133,44 @@ func NewPushClient(conf *PushConfig, store *PushStore, app *AppConfig, toDebug b
 	return
 }
 
+// SetDebug 打开或关闭当前client的调试模式
+//
+//	调试模式下输出请求和返回的详细内容，不影响其他client
+func (g *PushClient) SetDebug(debug bool) {
+	var v int32
+	if debug {
+		v = 1
+	}
+	atomic.StoreInt32(&g.debug, v)
+}
+
+// IsDebug 当前client是否为调试模式
+func (g *PushClient) IsDebug() bool {
+	return atomic.LoadInt32(&g.debug) == 1
+}
+
 // GetToken 获取token
 //
-//	从redis中或api中获取
+//	从redis(或 AppConfig.TokenStore)中或api中获取
 func (g *PushClient) GetToken() (token string, err error) {
-	rdb := goredis.GetRDB()
-	token, err = rdb.Get(ctx, g.Key).Result()
+	store, key := g.getTokenStore(), g.getTokenKey()
+	token, err = store.Get(key)
 	if err != nil {
-		logx.Errorf("%s 在redis中获取token失败 :%s", NAME, err.Error())
+		g.getTransport().logger.Log(LevelError, "获取存储的token失败", F("key", key), F("error", err.Error()))
 	}
 	if token == "" {
-		token, err = getToken(g.AppId, g.AppKey, g.MasterSecret)
+		t := g.getTransport()
+		token, err = getToken(t, g.AppId, g.AppKey, g.MasterSecret)
+		if t.metrics != nil {
+			t.metrics.ObserveTokenRefresh(g.AppId, err)
+		}
 		if err != nil {
 			err = fmt.Errorf("%s 从API获取token失败: %s", NAME, err.Error())
 			return
 		}
-		//_, err = rdb.SetEX(ctx, g.Key, token, time.Duration(expTime)).Result()
-		_, err = rdb.SetEx(ctx, g.Key, token, time.Duration(expTime)).Result()
+		err = store.Set(key, token, expTime)
 		if err != nil {
-			logx.Errorf("%s 在redis中存储token失败 :%s", NAME, err.Error())
+			g.getTransport().logger.Log(LevelError, "存储token失败", F("key", key), F("error", err.Error()))
 		}
 	}
 	return
@@ -146,54 +183,82 @@ func (g *PushClient) GetToken() (token string, err error) {
 */
 
 // BindAlias 绑定别名
+//
+//	param为nil或cid为空时返回 ErrEmptyCid，别名为空时返回 ErrEmptyAlias
 func (g *PushClient) BindAlias(param *models.Alias) (resp *models.Response, err error) {
-	if param == nil || param.Cid == "" {
-		err = errors.New("param未设置或cid为空")
+	aliasParam, err := newAliasParam(param)
+	if err != nil {
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	dataList := make([]*models.Alias, 0)
-	dataList = append(dataList, param)
-	aliasParam := &models.AliasParam{
-		DataList: dataList,
-	}
-	return bindAlias(g.AppId, token, aliasParam)
+	return bindAlias(g.getTransport(), g.AppId, token, aliasParam)
 }
 
 // UnBindAlias 解绑别名
 //
-//	cid与alias成对出现
+//	cid与alias成对出现，校验同 BindAlias
 func (g *PushClient) UnBindAlias(param *models.Alias) (resp *models.Response, err error) {
-	if param == nil || param.Cid == "" {
-		err = errors.New("param未设置或cid为空")
+	aliasParam, err := newAliasParam(param)
+	if err != nil {
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	dataList := make([]*models.Alias, 0)
-	dataList = append(dataList, param)
+	return unBindAlias(g.getTransport(), g.AppId, token, aliasParam)
+}
+
+// newAliasParam 校验一对cid和别名，返回绑定或解绑的参数
+func newAliasParam(param *models.Alias) (*models.AliasParam, error) {
+	if param == nil || strings.TrimSpace(param.Cid) == "" {
+		return nil, ErrEmptyCid
+	}
+	if strings.TrimSpace(param.Alias) == "" {
+		return nil, ErrEmptyAlias
+	}
 	aliasParam := &models.AliasParam{
-		DataList: dataList,
+		DataList: []*models.Alias{param},
+	}
+	if err := aliasParam.Validate(); err != nil {
+		return nil, err
 	}
-	return unBindAlias(g.AppId, token, aliasParam)
+	return aliasParam, nil
+}
+
+// BindAliases 批量绑定别名
+//
+//	cid与alias成对出现，超过1000对时会分批提交
+//	校验不通过或提交失败的cid/alias会记录在 result.Failed 中
+func (g *PushClient) BindAliases(list []*models.Alias) (result *models.AliasBatchResult, err error) {
+	return g.batchAlias(list, bindAlias)
+}
+
+// UnBindAliases 批量解绑别名
+//
+//	cid与alias成对出现，超过1000对时会分批提交
+//	校验不通过或提交失败的cid/alias会记录在 result.Failed 中
+func (g *PushClient) UnBindAliases(list []*models.Alias) (result *models.AliasBatchResult, err error) {
+	return g.batchAlias(list, unBindAlias)
 }
 
 // UnBindAllAlias 解绑所有与该别名绑定的cid
+//
+//	别名去掉首尾空格后为空时返回 ErrEmptyAlias
 func (g *PushClient) UnBindAllAlias(alias string) (resp *models.Response, err error) {
+	alias = strings.TrimSpace(alias)
 	if alias == "" {
-		err = errors.New("alias为空")
+		err = ErrEmptyAlias
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return unBindAllAlias(g.AppId, token, alias)
+	return unBindAllAlias(g.getTransport(), g.AppId, token, alias)
 }
 
 // GetUserCount 查询用户总量
@@ -202,11 +267,26 @@ func (g *PushClient) GetUserCount(tags []*models.Tag) (resp *models.Response, er
 		err = errors.New("tag为空")
 		return
 	}
+	if err = models.ByTags(tags...).Validate(); err != nil {
+		return
+	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return getUserCount(g.AppId, token, tags)
+	return getUserCount(g.getTransport(), g.AppId, token, tags)
+}
+
+// PreviewAudience 预估标签表达式可以推送到的用户数
+//
+//	可在 PushAllByLogicTags 前调用，确认推送范围
+func (g *PushClient) PreviewAudience(tags []*models.Tag) (count int64, err error) {
+	resp, err := g.GetUserCount(tags)
+	if err != nil {
+		return
+	}
+	count = gjson.Get(resp.Data, "user_count").Int()
+	return
 }
 
 /*
@@ -220,26 +300,35 @@ func (g *PushClient) GetUserCount(tags []*models.Tag) (resp *models.Response, er
 //	cid表示用户
 func (g *PushClient) BindTags(cid string, param *models.CustomTagsParam) (resp *models.Response, err error) {
 	if cid == "" {
-		err = errors.New("cid为空")
+		err = ErrEmptyCid
 		return
 	}
 	if param == nil {
 		err = errors.New("param为空")
 		return
 	}
-	if len(param.CustomTag) == 0 {
-		err = errors.New("自定义标签长度为0")
-		return
-	}
-	if len(param.CustomTag) > 100 {
-		err = errors.New("自定义标签长度大于100个")
+	if err = param.Validate(); err != nil {
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return bindTags(g.AppId, token, cid, param)
+	return bindTags(g.getTransport(), g.AppId, token, cid, param)
+}
+
+// BindTagToCids 一批用户绑定一个标签
+//
+//	当cid长度大于1000时，会分批提交；某一批失败不影响其他批次，失败的cid见 result.Failed
+func (g *PushClient) BindTagToCids(tag string, cid []string) (result *models.TagBatchResult, err error) {
+	return g.batchTag(tag, cid, bindTagBatch)
+}
+
+// UnBindTagFromCids 一批用户解绑一个标签
+//
+//	当cid长度大于1000时，会分批提交；某一批失败不影响其他批次，失败的cid见 result.Failed
+func (g *PushClient) UnBindTagFromCids(tag string, cid []string) (result *models.TagBatchResult, err error) {
+	return g.batchTag(tag, cid, unBindTagBatch)
 }
 
 /*
@@ -259,14 +348,14 @@ func (g *PushClient) BindTags(cid string, param *models.CustomTagsParam) (resp *
 */
 func (g *PushClient) SearchTags(cid string) (resp *models.Response, err error) {
 	if cid == "" {
-		err = errors.New("cid为空")
+		err = ErrEmptyCid
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return searchTags(g.AppId, token, cid)
+	return searchTags(g.getTransport(), g.AppId, token, cid)
 }
 
 // SearchStatus 查询某个用户的状态，是否在线，上次在线时间等
@@ -281,14 +370,14 @@ func (g *PushClient) SearchTags(cid string) (resp *models.Response, err error) {
 */
 func (g *PushClient) SearchStatus(cid string) (resp *models.Response, err error) {
 	if cid == "" {
-		err = errors.New("cid为空")
+		err = ErrEmptyCid
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return searchStatus(g.AppId, token, cid)
+	return searchStatus(g.getTransport(), g.AppId, token, cid)
 }
 
 // SearchUser 查询用户信息
@@ -312,14 +401,14 @@ func (g *PushClient) SearchStatus(cid string) (resp *models.Response, err error)
 */
 func (g *PushClient) SearchUser(cid string) (resp *models.Response, err error) {
 	if cid == "" {
-		err = errors.New("cid为空")
+		err = ErrEmptyCid
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	resp, err = searchUser(g.AppId, token, cid)
+	resp, err = searchUser(g.getTransport(), g.AppId, token, cid)
 	if err != nil {
 		return
 	}
@@ -333,14 +422,14 @@ func (g *PushClient) SearchUser(cid string) (resp *models.Response, err error) {
 */
 func (g *PushClient) SearchAliasByCid(cid string) (resp *models.Response, err error) {
 	if cid == "" {
-		err = errors.New("cid为空")
+		err = ErrEmptyCid
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return searchAliasByCid(g.AppId, token, cid)
+	return searchAliasByCid(g.getTransport(), g.AppId, token, cid)
 }
 
 // SearchCidByAlias 按alias查cid
@@ -354,15 +443,15 @@ func (g *PushClient) SearchAliasByCid(cid string) (resp *models.Response, err er
 }
 */
 func (g *PushClient) SearchCidByAlias(alias string) (resp *models.Response, err error) {
-	if alias == "" {
-		err = errors.New("别名为空")
+	if strings.TrimSpace(alias) == "" {
+		err = ErrEmptyAlias
 		return
 	}
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	return searchCidByAlias(g.AppId, token, alias)
+	return searchCidByAlias(g.getTransport(), g.AppId, token, alias)
 }
 
 // SearchTaskDetailByCid 可以查询某任务下某cid的具体实时推送路径情况
@@ -371,7 +460,7 @@ func (g *PushClient) SearchCidByAlias(alias string) (resp *models.Response, err
 //	此接口需要SVIP权限，暂时不可用
 func (g *PushClient) SearchTaskDetailByCid(cid, taskId string) (resp *models.TaskDetailResp, err error) {
 	if cid == "" {
-		err = errors.New("cid为空")
+		err = ErrEmptyCid
 		return
 	}
 	if taskId == "" {
@@ -382,7 +471,7 @@ func (g *PushClient) SearchTaskDetailByCid(cid, taskId string) (resp *models.Tas
 	if err != nil {
 		return
 	}
-	return searchTaskDetailByCid(g.AppId, token, cid, taskId)
+	return searchTaskDetailByCid(g.getTransport(), g.AppId, token, cid, taskId)
 }
 
 // ReportPushTask 获取推送结果（含自定义事件）可查询消息可下发数、下发数，接收数、展示数、点击数等结果
@@ -397,98 +486,281 @@ func (g *PushClient) ReportPushTask(taskId string) (resp *models.Response, err e
 	if err != nil {
 		return
 	}
-	return reportPushTask(g.AppId, token, taskId)
+	return reportPushTask(g.getTransport(), g.AppId, token, taskId)
+}
+
+// ReportPushTasks 查询多个任务的推送数据
+//
+//	返回值的key为taskId
+//	仅可以查询toList或toApp的推送结果数据
+//	taskId多于200个时，按每次200个分批查询
+func (g *PushClient) ReportPushTasks(taskIds ...string) (data map[string]*models.PushReport, err error) {
+	if len(taskIds) == 0 {
+		err = errors.New("taskid为空")
+		return
+	}
+	for _, taskId := range taskIds {
+		if strings.TrimSpace(taskId) == "" {
+			err = errors.New("taskid为空")
+			return
+		}
+		if strings.Contains(taskId, ",") {
+			err = fmt.Errorf("taskid %s 不能含有逗号", taskId)
+			return
+		}
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	t := g.getTransport()
+	data = make(map[string]*models.PushReport, len(taskIds))
+	pageCount := getPageCount(reportTaskLimit, len(taskIds))
+	for i := 1; i <= pageCount; i++ {
+		resp, err := reportPushTasks(t, g.AppId, token, getSplitCid(taskIds, i, reportTaskLimit))
+		if err != nil {
+			return data, err
+		}
+		for taskId, report := range resp.Data {
+			data[taskId] = report
+		}
+	}
+	return
+}
+
+// ReportPushGroup 查询任务组的推送数据
+//
+//	groupName 为推送时的group_name；没有推送数据时返回空的报表，与 ReportPushDate 一致
+func (g *PushClient) ReportPushGroup(groupName string) (data *models.PushReport, err error) {
+	if groupName == "" {
+		err = errors.New("任务组名为空")
+		return
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	resp, err := reportPushGroup(g.getTransport(), g.AppId, token, groupName)
+	if err != nil {
+		return
+	}
+	data = resp.Data[groupName]
+	if data == nil {
+		data = &models.PushReport{}
+	}
+	return
+}
+
+// ReportPushGroups 查询多个任务组的推送数据
+//
+//	返回值的key为任务组名
+func (g *PushClient) ReportPushGroups(groupNames ...string) (data map[string]*models.PushReport, err error) {
+	if len(groupNames) == 0 {
+		err = errors.New("任务组名为空")
+		return
+	}
+	data = make(map[string]*models.PushReport, len(groupNames))
+	for _, groupName := range groupNames {
+		report, err := g.ReportPushGroup(groupName)
+		if err != nil {
+			return data, err
+		}
+		data[groupName] = report
+	}
+	return
 }
 
 /*
 ===============================================================
-推给所有人
+统计数据
 ===============================================================
 */
 
-// PushAll 推送给所有人
+// ReportPushDate 查询单日推送数据
 //
-//	scheduleTime 定时推送时间戳，为0时，不定时
-func (g *PushClient) PushAll(msgType, scheduleTime int, payload *models.CustomMessage) (resp *models.Response, err error) {
+//	没有推送数据时返回空的报表
+func (g *PushClient) ReportPushDate(date time.Time) (data *models.PushReport, err error) {
 	token, err := g.GetToken()
 	if err != nil {
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, scheduleTime, payload)
+	day := date.Format(reportDateLayout)
+	resp, err := reportPushDate(g.getTransport(), g.AppId, token, day)
 	if err != nil {
 		return
 	}
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    "all",
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
+	data = resp.Data[day]
+	if data == nil {
+		data = &models.PushReport{}
+	}
+	return
+}
+
+// ReportPushDates 按天查询一段日期内的推送数据
+//
+//	包含start和end当天，返回值的key为日期，格式：yyyy-MM-dd
+//	日期范围的规则见 ReportDateRange
+func (g *PushClient) ReportPushDates(start, end time.Time) (data map[string]*models.PushReport, err error) {
+	days, err := ReportDateRange(start, end)
+	if err != nil {
+		return
+	}
+	data = make(map[string]*models.PushReport, len(days))
+	for _, day := range days {
+		report, err := g.ReportPushDate(day)
+		if err != nil {
+			return data, err
+		}
+		data[day.Format(reportDateLayout)] = report
+	}
+	return
+}
+
+// ReportUserDate 查询单日用户数据
+//
+//	包括累计用户数、新增用户数、活跃用户数、在线用户数
+func (g *PushClient) ReportUserDate(date time.Time) (data *models.UserReport, err error) {
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	day := date.Format(reportDateLayout)
+	resp, err := reportUserDate(g.getTransport(), g.AppId, token, day)
+	if err != nil {
+		return
+	}
+	data = resp.Data[day]
+	if data == nil {
+		data = &models.UserReport{}
+	}
+	return
+}
+
+// ReportUserDates 按天查询一段日期内的用户数据
+//
+//	包含start和end当天，返回值的key为日期，格式：yyyy-MM-dd
+//	日期范围的规则见 ReportDateRange
+func (g *PushClient) ReportUserDates(start, end time.Time) (data map[string]*models.UserReport, err error) {
+	days, err := ReportDateRange(start, end)
+	if err != nil {
+		return
+	}
+	data = make(map[string]*models.UserReport, len(days))
+	for _, day := range days {
+		report, err := g.ReportUserDate(day)
+		if err != nil {
+			return data, err
+		}
+		data[day.Format(reportDateLayout)] = report
 	}
+	return
+}
 
-	resp, err = pushApp(g.AppId, token, pushParam)
+// ReportOnlineUser 查询24小时在线用户数
+//
+//	返回值的key为毫秒时间戳
+func (g *PushClient) ReportOnlineUser() (data map[string]int64, err error) {
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	resp, err := reportOnlineUser(g.getTransport(), g.AppId, token)
 	if err != nil {
 		return
 	}
+	data = make(map[string]int64)
+	if resp.Data != nil {
+		data = resp.Data.OnlineStatics
+	}
 	return
 }
 
 /*
 ===============================================================
-推给指定端类型
+推给所有人
 ===============================================================
 */
 
-// PushAllByClient 推送给不同的客户端
+// PushAll 推送给所有人
 //
-//	clientType 客户端类型，只能选1种
-//	scheduleTime 定时推送时间戳，为0时，不定时
-func (g *PushClient) PushAllByClient(msgType, scheduleTime int, clientType ClientType, payload *models.CustomMessage) (resp *models.Response, err error) {
-	token, err := g.GetToken()
-	if err != nil {
+//	scheduleTime 定时推送的毫秒时间戳，为0时，不定时；必须是7天之内的时间
+//	resp.TaskId 为任务id，定时推送时可用于 GetScheduleTask 和 CancelScheduleTask
+func (g *PushClient) PushAll(msgType, scheduleTime int, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, scheduleTime, payload)
+	message, err := newMessage(payload)
 	if err != nil {
 		return
 	}
-	var phones []string
-	switch clientType {
-	case Android:
-		phones = []string{"android"}
-	case IOS:
-		phones = []string{"ios"}
-	case WechatAPP:
-		//TODO:
+	pushParam, err := g.newPushParam(o, msgType, scheduleTime, models.All(), message)
+	if err != nil {
+		return
 	}
+	return g.doPush(o, pathPushAll, pushParam, pushApp)
+}
 
-	tag := make([]*models.Tag, 0)
-	tag = append(tag, &models.Tag{
-		Key:     "phone_type",
-		Values:  phones,
-		OptType: "or",
-	})
+// PushAllAt 定时推送给所有人
+//
+//	scheduleTime 必须是当前时间之后，7天之内的时间，否则返回 *ScheduleTimeError
+func (g *PushClient) PushAllAt(msgType int, scheduleTime time.Time, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	ms, err := getScheduleMillis(scheduleTime)
+	if err != nil {
+		return
+	}
+	return g.PushAll(msgType, ms, payload, opts...)
+}
 
-	audience := struct {
-		Tag []*models.Tag `json:"tag"`
-	}{}
+/*
+===============================================================
+推给指定端类型
+===============================================================
+*/
 
-	audience.Tag = tag
+// PushAllByClient 推送给不同的客户端
+//
+//	clientType 客户端类型，只能选1种：Android IOS Harmony；个推不支持推送到微信小程序
+//	需要同时推送多种客户端时，使用 PushAllByClients
+//	scheduleTime 定时推送的毫秒时间戳，为0时，不定时；必须是7天之内的时间
+func (g *PushClient) PushAllByClient(msgType, scheduleTime int, clientType ClientType, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	return g.PushAllByClients(msgType, scheduleTime, []ClientType{clientType}, payload, opts...)
+}
 
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    audience,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
+// PushAllByClients 推送给多种客户端
+//
+//	clientTypes 客户端类型，可选多种，如 Android+Harmony
+//	需要与自定义标签、地区等条件组合时，使用 AudienceBuilder 和 PushAllByLogicTags
+//	scheduleTime 定时推送的毫秒时间戳，为0时，不定时；必须是7天之内的时间
+func (g *PushClient) PushAllByClients(msgType, scheduleTime int, clientTypes []ClientType, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	audience, err := NewAudienceBuilder().ClientTypes(clientTypes...).Build()
+	if err != nil {
+		return
+	}
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
+		return
 	}
-	resp, err = pushAppByClient(g.AppId, token, pushParam)
+	message, err := newMessage(payload)
 	if err != nil {
 		return
 	}
-	return
+	pushParam, err := g.newPushParam(o, msgType, scheduleTime, audience, message)
+	if err != nil {
+		return
+	}
+	return g.doPush(o, pathPushTag, pushParam, pushAppByClient)
+}
+
+// PushAllByClientAt 定时推送给不同的客户端
+//
+//	scheduleTime 必须是当前时间之后，7天之内的时间，否则返回 *ScheduleTimeError
+func (g *PushClient) PushAllByClientAt(msgType int, scheduleTime time.Time, clientType ClientType, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	ms, err := getScheduleMillis(scheduleTime)
+	if err != nil {
+		return
+	}
+	return g.PushAllByClient(msgType, ms, clientType, payload, opts...)
 }
 
 /*
@@ -501,32 +773,24 @@ func (g *PushClient) PushAllByClient(msgType, scheduleTime int, clientType Clien
 //
 //	cid = 用户的cid信息
 //	channelType = 通道类型
-func (g *PushClient) PushSingleByCid(msgType int, cid string, payload *models.CustomMessage) (resp *models.Response, err error) {
-	token, err := g.GetToken()
-	if err != nil {
+func (g *PushClient) PushSingleByCid(msgType int, cid string, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	if strings.TrimSpace(cid) == "" {
+		err = ErrEmptyCid
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, 0, payload)
-	if err != nil {
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
 		return
 	}
-	audience := struct {
-		Cid []string `json:"cid"`
-	}{}
-	audience.Cid = []string{cid}
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    audience,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
+	message, err := newMessage(payload)
+	if err != nil {
+		return
 	}
-	resp, err = pushSingleByCid(g.AppId, token, pushParam)
+	pushParam, err := g.newPushParam(o, msgType, 0, models.ByCids(cid), message)
 	if err != nil {
 		return
 	}
-	return
+	return g.doPush(o, pathPushSingleCid, pushParam, pushSingleByCid)
 }
 
 /*
@@ -539,33 +803,24 @@ func (g *PushClient) PushSingleByCid(msgType int, cid string, payload *models.Cu
 //
 //	alias = 用户的alias
 //	channelType = 通道类型
-func (g *PushClient) PushSingleByAlias(msgType int, alias string, payload *models.CustomMessage) (resp *models.Response, err error) {
-	token, err := g.GetToken()
-	if err != nil {
+func (g *PushClient) PushSingleByAlias(msgType int, alias string, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	if strings.TrimSpace(alias) == "" {
+		err = ErrEmptyAlias
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, 0, payload)
-	if err != nil {
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
 		return
 	}
-
-	audience := struct {
-		Alias []string `json:"alias"`
-	}{}
-	audience.Alias = []string{alias}
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    audience,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
+	message, err := newMessage(payload)
+	if err != nil {
+		return
 	}
-	resp, err = pushSingleByAlias(g.AppId, token, pushParam)
+	pushParam, err := g.newPushParam(o, msgType, 0, models.ByAliases(alias), message)
 	if err != nil {
 		return
 	}
-	return
+	return g.doPush(o, pathPushSingleAlias, pushParam, pushSingleByAlias)
 }
 
 /*
@@ -577,53 +832,87 @@ func (g *PushClient) PushSingleByAlias(msgType int, alias string, payload *model
 // PushListByCid 按cid群推消息
 //
 //	当cid长度大于1000时，会分页循环进行推送
-func (g *PushClient) PushListByCid(msgType int, cid []string, payload *models.CustomMessage) (data []*models.Response, err error) {
-	if len(cid) == 0 {
-		err = errors.New("cid长度为0")
+func (g *PushClient) PushListByCid(msgType int, cid []string, payload *models.CustomMessage, opts ...PushOption) (data []*models.Response, err error) {
+	message, err := newMessage(payload)
+	if err != nil {
 		return
 	}
-	token, err := g.GetToken()
-	if err != nil {
+	return g.pushList(getPushOptions(opts), msgType, cid, message)
+}
+
+// pushList 先创建消息，再按cid分批群推
+func (g *PushClient) pushList(o *pushOptions, msgType int, cid []string, message *models.Message) (data []*models.Response, err error) {
+	if err = o.checkNoSchedule(); err != nil {
+		return
+	}
+	if err = checkCids(cid); err != nil {
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, 0, payload)
+	pushParam, err := g.newPushParam(o, msgType, 0, nil, message)
 	if err != nil {
 		return
 	}
 
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
+	// 先校验每一页的cid，避免消息创建后才发现参数错误；taskid 在创建消息后设置，发送前再校验整个参数
+	pageCount := getPageCount(limit, len(cid))
+	pages := make([]*models.PushListParam, 0, pageCount)
+	for i := 1; i <= pageCount; i++ {
+		list := getSplitCid(cid, i, limit)
+		if err = models.ValidateCids(list); err != nil {
+			return
+		}
+		pushListParam := &models.PushListParam{}
+		pushListParam.Audience.Cid = list //每次的推送列表
+		pushListParam.IsAsync = false     //不异步
+		pages = append(pages, pushListParam)
+	}
+
+	t := g.getCallTransport(o)
+	dryRun := g.getDryRun(o)
+	token := ""
+	if dryRun == nil {
+		token, err = g.GetToken()
+		if err != nil {
+			return
+		}
 	}
 
 	// 创建消息
-	resp, err := createPushMessage(g.AppId, token, pushParam)
+	var resp *models.Response
+	if dryRun != nil {
+		resp, err = dryRun.record("POST", g.AppId+pathPushListMessage, pushParam)
+	} else {
+		resp, err = createPushMessage(t, g.AppId, token, pushParam)
+	}
 	if err != nil {
 		err = fmt.Errorf("%s 保存消息失败: %s", NAME, err.Error())
 		return
 	}
 	//返回的taskId
-	taskId := resp.Data
-	pageCount := getPageCount(limit, len(cid))
+	taskId := resp.TaskId
+	if taskId == "" {
+		err = fmt.Errorf("%s 保存消息失败: 未返回taskid", NAME)
+		return
+	}
 	data = make([]*models.Response, 0)
 
 	// 分页群推
-	for i := 1; i <= pageCount; i++ {
-		list := getSplitCid(cid, i, limit)
-		pushListParam := &models.PushListParam{
-			TaskId: taskId,
+	for _, pushListParam := range pages {
+		pushListParam.TaskId = taskId
+		if err = pushListParam.Validate(); err != nil {
+			return
 		}
 
-		pushListParam.Audience.Cid = list //每次的推送列表
-		pushListParam.IsAsync = false     //不异步
+		if dryRun != nil {
+			respList, _ := dryRun.record("POST", g.AppId+pathPushListCid, pushListParam)
+			data = append(data, respList)
+			continue
+		}
 
-		respList, err := pushListByCid(g.AppId, token, pushListParam)
+		respList, err := pushListByCid(t, g.AppId, token, pushListParam)
 
 		if err != nil {
-			logx.Errorf("%s 按cid群推失败: %s", NAME, err.Error())
+			t.logger.Log(LevelError, "按cid群推失败", F("task_id", taskId), F("error", err.Error()))
 		}
 		data = append(data, respList)
 		time.Sleep(time.Microsecond * 500) //休眠500ms
@@ -641,88 +930,64 @@ func (g *PushClient) PushListByCid(msgType int, cid []string, payload *models.Cu
 // PushAllByCustomTag 对指定应用的符合筛选条件的用户群发推送消息。支持定时、定速功能
 //
 //	此接口频次限制100次/天，每分钟不能超过5次(推送限制和接口执行群推共享限制)，定时推送功能需要申请开通才可以使用
-//	scheduleTime 定时推送时间戳，为0时，不定时
-//	customTag 内的标签是交集的关系
-func (g *PushClient) PushAllByCustomTag(msgType, scheduleTime int, customTag []string, payload *models.CustomMessage) (resp *models.Response, err error) {
+//	scheduleTime 定时推送的毫秒时间戳，为0时，不定时；必须是7天之内的时间
+//	customTag 内的标签是并集的关系，用户绑定了其中任意一个标签即可
+func (g *PushClient) PushAllByCustomTag(msgType, scheduleTime int, customTag []string, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
 	if len(customTag) == 0 {
 		err = errors.New("自定义标签长度为0")
 		return
 	}
-	token, err := g.GetToken()
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
+		return
+	}
+	message, err := newMessage(payload)
 	if err != nil {
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, scheduleTime, payload)
+	pushParam, err := g.newPushParam(o, msgType, scheduleTime, models.ByTags(models.TagCustom(customTag...)), message)
 	if err != nil {
 		return
 	}
+	return g.doPush(o, pathPushTag, pushParam, pushAppByTag)
+}
 
-	tags := make([]*models.Tag, 0)
-	tags = append(tags, &models.Tag{
-		Key:     "custom_tag",
-		Values:  customTag,
-		OptType: "or",
-	})
-
-	audience := struct {
-		Tag []*models.Tag `json:"tag"`
-	}{}
-
-	audience.Tag = tags
-
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    audience,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
-	}
-	resp, err = pushAppByTag(g.AppId, token, pushParam)
+// PushAllByCustomTagAt 定时对符合筛选条件的用户群发推送消息
+//
+//	scheduleTime 必须是当前时间之后，7天之内的时间，否则返回 *ScheduleTimeError
+//	定时推送功能需要申请开通才可以使用
+func (g *PushClient) PushAllByCustomTagAt(msgType int, scheduleTime time.Time, customTag []string, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
+	ms, err := getScheduleMillis(scheduleTime)
 	if err != nil {
 		return
 	}
-	return
+	return g.PushAllByCustomTag(msgType, ms, customTag, payload, opts...)
 }
 
 // PushAllByLogicTags 对指定应用的符合筛选条件的用户群发推送消息。支持定时、定速功能
 //
 //	此接口频次限制100次/天，每分钟不能超过5次(推送限制和接口执行群推共享限制)，定时推送功能需要申请开通才可以使用
-//	scheduleTime 定时推送时间戳，为0时，不定时
-//	tags为[]*models.Tag，需要自己构建tag表达式
+//	scheduleTime 定时推送的毫秒时间戳，为0时，不定时；必须是7天之内的时间
+//	tags为[]*models.Tag，需要自己构建tag表达式，或使用 AudienceBuilder 构建
 //	see @https://docs.getui.com/getui/server/rest_v2/push/
-func (g *PushClient) PushAllByLogicTags(msgType, scheduleTime int, tags []*models.Tag, payload *models.CustomMessage) (resp *models.Response, err error) {
+func (g *PushClient) PushAllByLogicTags(msgType, scheduleTime int, tags []*models.Tag, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
 	if len(tags) == 0 {
 		err = errors.New("标签表达式长度为0")
 		return
 	}
-	token, err := g.GetToken()
-	if err != nil {
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, scheduleTime, payload)
+	message, err := newMessage(payload)
 	if err != nil {
 		return
 	}
-	audience := struct {
-		Tag []*models.Tag `json:"tag"`
-	}{}
-
-	audience.Tag = tags
-
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    audience,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
-	}
-	resp, err = pushAppByTag(g.AppId, token, pushParam)
+	pushParam, err := g.newPushParam(o, msgType, scheduleTime, models.ByTags(tags...), message)
 	if err != nil {
 		return
 	}
-	return
+	return g.doPush(o, pathPushTag, pushParam, pushAppByTag)
 }
 
 /*
@@ -736,43 +1001,136 @@ func (g *PushClient) PushAllByLogicTags(msgType, scheduleTime int, tags []*model
 //	tag 为某一个标签名
 //	scheduleTime 为定时任务的时间戳
 //	此接口需要SVIP才有使用权限
-func (g *PushClient) PushAppByFastCustomTag(msgType, scheduleTime int, tag string, payload *models.CustomMessage) (resp *models.Response, err error) {
+func (g *PushClient) PushAppByFastCustomTag(msgType, scheduleTime int, tag string, payload *models.CustomMessage, opts ...PushOption) (resp *models.Response, err error) {
 	if tag == "" {
 		err = errors.New("自定义标签长度为0")
 		return
 	}
-	token, err := g.GetToken()
+	o := getPushOptions(opts)
+	if err = o.checkNoSchedule(); err != nil {
+		return
+	}
+	message, err := newMessage(payload)
 	if err != nil {
 		return
 	}
-	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(msgType, scheduleTime, payload)
+	pushParam, err := g.newPushParam(o, msgType, scheduleTime, models.ByFastTag(tag), message)
 	if err != nil {
 		return
 	}
+	return g.doPush(o, pathPushFastCustomTag, pushParam, pushAppByFastCustomTag)
+}
 
-	audience := struct {
-		FastCustomTag string `json:"fast_custom_tag"`
-	}{}
+/*
+===============================================================
+推送自定义内容的消息
+===============================================================
+*/
 
-	audience.FastCustomTag = tag
-	pushParam := &models.PushParam{
-		GroupName:   getGroupName(),
-		RequestId:   getRandString(),
-		Setting:     setting,
-		Audience:    audience,
-		PushMessage: pushMessage,
-		PushChannel: pushChannel,
+// PushMessage 推送自定义内容的消息
+//
+//	message.Payload 可以是任意能序列化为json的值，作为透传内容和iOS的payload；Title Body 用于厂商通知
+//	按 audience 选择接口：单个cid、单个别名、标签、快速标签或所有人；多个cid请使用 PushListMessage
+//	定时推送使用 WithScheduleTime，单推不支持定时
+func (g *PushClient) PushMessage(msgType int, audience *models.Audience, message *models.Message, opts ...PushOption) (resp *models.Response, err error) {
+	return g.pushMessage(getPushOptions(opts), msgType, audience, message)
+}
+
+// PushVoIP 推送voip消息，用于音视频通话的来电
+//
+//	iOS走 PushKit，只下发 message.Payload，不展示通知，客户端收到后需立即上报 CallKit
+//	android和鸿蒙按 msgType 下发 Title Body 的通知，一般为 InstantMsg
+//	audience 同 PushMessage，但不支持多个cid，也不支持定时推送
+func (g *PushClient) PushVoIP(msgType int, audience *models.Audience, message *models.Message, opts ...PushOption) (resp *models.Response, err error) {
+	o := getPushOptions(opts)
+	o.kind = kindVoIP
+	return g.pushMessage(o, msgType, audience, message)
+}
+
+// PushSilent 推送静默消息，用于唤醒app在后台刷新数据
+//
+//	iOS为 content-available=1，没有alert和铃声，也不修改badge
+//	android只走透传，鸿蒙不下发通知；Title Body 不会展示
+//	audience 同 PushMessage，但不支持多个cid，也不支持定时推送
+func (g *PushClient) PushSilent(audience *models.Audience, message *models.Message, opts ...PushOption) (resp *models.Response, err error) {
+	o := getPushOptions(opts)
+	o.kind = kindSilent
+	return g.pushMessage(o, 0, audience, message)
+}
+
+// pushMessage 按 audience 选择接口推送消息
+func (g *PushClient) pushMessage(o *pushOptions, msgType int, audience *models.Audience, message *models.Message) (resp *models.Response, err error) {
+	if o.kind != kindNotify && !o.scheduleTime.IsZero() {
+		err = ErrScheduleNotSupported
+		return
 	}
-	resp, err = pushAppByFastCustomTag(g.AppId, token, pushParam)
+	if audience != nil && audience.Cid != nil {
+		if err = checkCids(audience.Cid); err != nil {
+			return
+		}
+	}
+	if audience != nil && audience.Alias != nil {
+		if err = checkAliases(audience.Alias); err != nil {
+			return
+		}
+	}
+	if err = audience.Validate(); err != nil {
+		return
+	}
+	var (
+		path string
+		fn   func(t *transport, appId, token string, param *models.PushParam) (*models.Response, error)
+	)
+	switch {
+	case audience.IsAll():
+		path, fn = pathPushAll, pushApp
+	case len(audience.Cid) == 1:
+		path, fn = pathPushSingleCid, pushSingleByCid
+	case len(audience.Cid) > 1 && o.kind != kindNotify:
+		err = errors.New("voip和静默消息不支持多个cid")
+		return
+	case len(audience.Cid) > 1:
+		err = errors.New("多个cid请使用 PushListMessage")
+		return
+	case len(audience.Alias) == 1:
+		path, fn = pathPushSingleAlias, pushSingleByAlias
+	case len(audience.Alias) > 1:
+		err = errors.New("按别名只能推送给一个用户")
+		return
+	case audience.Tag != nil:
+		path, fn = pathPushTag, pushAppByTag
+	default:
+		path, fn = pathPushFastCustomTag, pushAppByFastCustomTag
+	}
+
+	scheduleTime := 0
+	if !o.scheduleTime.IsZero() {
+		if audience.Cid != nil || audience.Alias != nil {
+			err = ErrScheduleNotSupported
+			return
+		}
+		scheduleTime, err = getScheduleMillis(o.scheduleTime)
+		if err != nil {
+			return
+		}
+	}
+	pushParam, err := g.newPushParam(o, msgType, scheduleTime, audience, message)
 	if err != nil {
 		return
 	}
-	return
+	return g.doPush(o, path, pushParam, fn)
+}
+
+// PushListMessage 按cid群推自定义内容的消息
+//
+//	当cid长度大于1000时，会分页循环进行推送
+func (g *PushClient) PushListMessage(msgType int, cid []string, message *models.Message, opts ...PushOption) (data []*models.Response, err error) {
+	return g.pushList(getPushOptions(opts), msgType, cid, message)
 }
 
 /*
 ===============================================================
-使用标签快速推送
+任务管理
 ===============================================================
 */
 
@@ -788,7 +1146,64 @@ func (g *PushClient) StopTask(taskId string) (resp *models.Response, err error)
 	if err != nil {
 		return
 	}
-	return stopTask(g.AppId, token, taskId)
+	return stopTask(g.getTransport(), g.AppId, token, taskId)
+}
+
+// GetScheduleTask 查询定时任务的状态
+//
+//	taskId 为定时推送返回的 Response.TaskId
+func (g *PushClient) GetScheduleTask(taskId string) (task *models.ScheduleTask, err error) {
+	if taskId == "" {
+		err = errors.New("taskid为空")
+		return
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	resp, err := searchSchedule(g.getTransport(), g.AppId, token, taskId)
+	if err != nil {
+		return
+	}
+	task = resp.Data[taskId]
+	if task == nil {
+		err = fmt.Errorf("%s 定时任务 %s 不存在", NAME, taskId)
+	}
+	return
+}
+
+// GetScheduleTasks 查询多个定时任务的状态
+//
+//	返回值的key为taskId
+func (g *PushClient) GetScheduleTasks(taskIds ...string) (data map[string]*models.ScheduleTask, err error) {
+	if len(taskIds) == 0 {
+		err = errors.New("taskid为空")
+		return
+	}
+	data = make(map[string]*models.ScheduleTask, len(taskIds))
+	for _, taskId := range taskIds {
+		task, err := g.GetScheduleTask(taskId)
+		if err != nil {
+			return data, err
+		}
+		data[taskId] = task
+	}
+	return
+}
+
+// CancelScheduleTask 取消定时任务
+//
+//	只能删除还未下发的定时任务
+func (g *PushClient) CancelScheduleTask(taskId string) (resp *models.Response, err error) {
+	if taskId == "" {
+		err = errors.New("taskid为空")
+		return
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	return deleteSchedule(g.getTransport(), g.AppId, token, taskId)
 }
 
 /*
@@ -797,13 +1212,12 @@ private
 
 // getPushMessageAndChannel 构造消息
 //
-//	channelType 通道
+//	kind iOS消息的类型：通知、voip或静默消息
 //	msgType 消息类型
 //	scheduleTime 定时任务的时间戳
-//	payload 消息结构体
-func (m *PushClient) getPushMessageAndChannel(msgType int, scheduleTime int, payload *models.CustomMessage) (pushMessage *models.PushMessage, pushChannel *models.PushChannel, setting *models.Setting, err error) {
-	payload.Title = strings.TrimSpace(payload.Title)
-	pushInfo, err := json.Marshal(payload)
+//	message 消息，Title Body 用于通知，Payload 为透传内容
+func (m *PushClient) getPushMessageAndChannel(kind pushKind, msgType int, scheduleTime int, message *models.Message) (pushMessage *models.PushMessage, pushChannel *models.PushChannel, setting *models.Setting, err error) {
+	transmission, err := message.Transmission()
 	if err != nil {
 		return
 	}
@@ -822,13 +1236,16 @@ func (m *PushClient) getPushMessageAndChannel(msgType int, scheduleTime int, pay
 	setting.Strategy.XM = 1
 
 	if scheduleTime > 0 {
+		if err = checkScheduleTime(time.Unix(0, int64(scheduleTime)*int64(time.Millisecond))); err != nil {
+			return
+		}
 		setting.ScheduleTime = scheduleTime
 	}
 
 	// 个推消息，走透传模式
 	// TODO:此处可测试是否可走 通知消息模式
 	pushMessage = &models.PushMessage{
-		Transmission: string(pushInfo),
+		Transmission: transmission,
 	}
 
 	// 个推消息，走通知模式
@@ -848,25 +1265,44 @@ func (m *PushClient) getPushMessageAndChannel(msgType int, scheduleTime int, pay
 
 	// iOS消息配置
 	ios := &models.IOSChannel{
-		Payload:   string(pushInfo),
-		Type:      "notify",
-		AutoBadge: "+1",
+		Payload: transmission,
+		Type:    models.IOSTypeNotify,
+	}
+	switch kind {
+	case kindVoIP:
+		// voip只有payload，由客户端的 PushKit 处理
+		ios.Type = models.IOSTypeVoIP
+	case kindSilent:
+		// 静默消息不能有alert、sound，也不修改badge
+		ios.Aps = &models.IOSAps{ContentAvailable: 1}
+	default:
+		ios.AutoBadge = "+1"
+		ios.Aps = &models.IOSAps{
+			Alert: &models.IOSAlert{
+				Title: message.Title,
+				Body:  message.Body,
+			},
+			ContentAvailable: 0,         //通知消息 =1时为静默消息
+			Sound:            "default", //铃声
+		}
+		message.IOS.Apply(ios)
 	}
-	ios.Aps.ContentAvailable = 0 //通知消息 =1时为静默消息
-	ios.Aps.Sound = "default"    //铃声
-	ios.Aps.Alert.Title = payload.Title
-	ios.Aps.Alert.Body = payload.Content
 
 	// android 消息配置
 	android := &models.AndroidChannel{}
 
-	//走厂商的通知消息
-	android.Ups.Notification = &models.UPSNotification{
-		Title:     payload.Title,
-		Body:      payload.Content,
-		ClickType: "intent", //打开应用内特定页面(厂商都支持)
-		Intent:    getIntent(payload.Url),
-		NotifyId:  uint(time.Now().Unix()),
+	if kind == kindSilent {
+		//静默消息走厂商的透传，不展示通知
+		android.Ups.Transmission = transmission
+	} else {
+		//走厂商的通知消息
+		android.Ups.Notification = &models.UPSNotification{
+			Title:     message.Title,
+			Body:      message.Body,
+			ClickType: "intent", //打开应用内特定页面(厂商都支持)
+			Intent:    getIntent(message.Url),
+			NotifyId:  uint(time.Now().Unix()),
+		}
 	}
 
 	// android 离线推送通道
@@ -922,12 +1358,12 @@ func (m *PushClient) getPushMessageAndChannel(msgType int, scheduleTime int, pay
 		IOS:     ios,
 	}
 
-	// harmony 厂商通知 配置
-	if m.AppConfig != nil && m.AppConfig.Harmony != nil {
+	// harmony 厂商通知 配置，静默消息不展示通知
+	if m.AppConfig != nil && m.AppConfig.Harmony != nil && kind != kindSilent {
 		harmony := &models.HarmonyChannel{}
 		harmony.Notification = &models.HarmonyNotification{
-			Title:     payload.Title,
-			Body:      payload.Content,
+			Title:     message.Title,
+			Body:      message.Body,
 			Category:  "",
 			ClickType: "want",
 			Payload:   "",
@@ -944,7 +1380,7 @@ func (m *PushClient) getPushMessageAndChannel(msgType int, scheduleTime int, pay
 		//parameters中添加"gttask":""参数后，个推会自动在 [want] 里拼接 taskid 和 actionid，app 端接收到参数可以用于上报点击埋点
 		param := make(map[string]interface{})
 		param["gttask"] = ""
-		param["data"] = payload
+		param["data"] = getWantData(transmission)
 		wantData.Parameters = param
 		b, _ := json.Marshal(wantData)
 		harmony.Notification.Want = string(b)
@@ -956,6 +1392,326 @@ func (m *PushClient) getPushMessageAndChannel(msgType int, scheduleTime int, pay
 	return
 }
 
+// newMessage 把 CustomMessage 转换为 Message
+//
+//	CustomMessage 序列化后作为透传内容，Title Content 用于通知
+//	标题去掉首尾空格后使用副本，不修改调用方的payload
+func newMessage(payload *models.CustomMessage) (*models.Message, error) {
+	if payload == nil {
+		return nil, ErrNilPayload
+	}
+	if *payload == (models.CustomMessage{}) {
+		return nil, ErrEmptyPayload
+	}
+	p := *payload
+	p.Title = strings.TrimSpace(p.Title)
+	return &models.Message{
+		Title:   p.Title,
+		Body:    p.Content,
+		Url:     p.Url,
+		Payload: &p,
+	}, nil
+}
+
+// checkCids 校验cid列表不为空，且不含空的cid
+func checkCids(cid []string) error {
+	if len(cid) == 0 {
+		return ErrEmptyCid
+	}
+	for _, c := range cid {
+		if strings.TrimSpace(c) == "" {
+			return ErrEmptyCid
+		}
+	}
+	return nil
+}
+
+// checkAliases 校验别名列表不为空，且不含空的别名
+func checkAliases(alias []string) error {
+	if len(alias) == 0 {
+		return ErrEmptyAlias
+	}
+	for _, a := range alias {
+		if strings.TrimSpace(a) == "" {
+			return ErrEmptyAlias
+		}
+	}
+	return nil
+}
+
+// checkMessage 校验消息不为nil，且透传内容不为空
+func checkMessage(message *models.Message) error {
+	if message == nil {
+		return ErrNilPayload
+	}
+	switch v := message.Payload.(type) {
+	case nil:
+		return ErrEmptyPayload
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return ErrEmptyPayload
+		}
+	case []byte:
+		if len(v) == 0 {
+			return ErrEmptyPayload
+		}
+	case json.RawMessage:
+		if len(v) == 0 {
+			return ErrEmptyPayload
+		}
+	}
+	return nil
+}
+
+// getWantData 返回鸿蒙want中的data参数
+//
+//	透传内容为json时原样嵌入，否则作为字符串
+func getWantData(transmission string) interface{} {
+	if json.Valid([]byte(transmission)) {
+		return json.RawMessage(transmission)
+	}
+	return transmission
+}
+
+// batchAlias 批量绑定或解绑别名
+//
+//	先逐个校验cid和alias，再按 aliasLimit 分批调用 fn
+func (g *PushClient) batchAlias(list []*models.Alias, fn func(t *transport, appId, token string, param *models.AliasParam) (*models.Response, error)) (result *models.AliasBatchResult, err error) {
+	if len(list) == 0 {
+		err = errors.New("别名列表为空")
+		return
+	}
+	result = &models.AliasBatchResult{
+		Responses: make([]*models.Response, 0),
+		Failed:    make([]*models.AliasFailure, 0),
+	}
+	valid := make([]*models.Alias, 0, len(list))
+	for _, item := range list {
+		if e := checkAlias(item); e != nil {
+			result.Failed = append(result.Failed, &models.AliasFailure{Alias: item, Reason: e.Error()})
+			continue
+		}
+		valid = append(valid, item)
+	}
+	if len(valid) == 0 {
+		return
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	pageCount := getPageCount(aliasLimit, len(valid))
+	for i := 1; i <= pageCount; i++ {
+		dataList := getSplitAlias(valid, i, aliasLimit)
+		resp, e := fn(g.getTransport(), g.AppId, token, &models.AliasParam{DataList: dataList})
+		if e != nil {
+			g.getTransport().logger.Log(LevelError, "批量处理别名失败", F("error", e.Error()))
+			for _, item := range dataList {
+				result.Failed = append(result.Failed, &models.AliasFailure{Alias: item, Reason: e.Error()})
+			}
+			continue
+		}
+		result.Responses = append(result.Responses, resp)
+	}
+	return
+}
+
+// checkAlias 校验cid和别名
+//
+//	别名只能由字母、数字、下划线、汉字组成，长度小于40字节
+func checkAlias(item *models.Alias) error {
+	return item.Validate()
+}
+
+// batchTag 一批用户绑定或解绑一个标签
+//
+//	按 tagCidLimit 分批调用 fn，失败的批次记录到 result.Failed
+func (g *PushClient) batchTag(tag string, cid []string, fn func(t *transport, appId, token, tag string, param *models.TagCidParam) (*models.Response, error)) (result *models.TagBatchResult, err error) {
+	if err = checkCustomTags([]string{tag}); err != nil {
+		return
+	}
+	if len(cid) == 0 {
+		err = ErrEmptyCid
+		return
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	result = &models.TagBatchResult{
+		Responses: make([]*models.Response, 0),
+		Failed:    make([]*models.TagFailure, 0),
+	}
+	pageCount := getPageCount(tagCidLimit, len(cid))
+	for i := 1; i <= pageCount; i++ {
+		param := &models.TagCidParam{
+			Cid: getSplitCid(cid, i, tagCidLimit),
+		}
+		resp, e := fn(g.getTransport(), g.AppId, token, tag, param)
+		if e != nil {
+			g.getTransport().logger.Log(LevelError, "批量处理标签失败", F("tag", tag), F("error", e.Error()))
+			result.Failed = append(result.Failed, &models.TagFailure{Cid: param.Cid, Reason: e.Error()})
+			continue
+		}
+		result.Responses = append(result.Responses, resp)
+	}
+	return
+}
+
+// checkCustomTags 校验自定义标签
+//
+//	最多100个标签；单个标签长度最大为32字符，标签总长度最大为512个字符
+func checkCustomTags(tags []string) error {
+	return (&models.CustomTagsParam{CustomTag: tags}).Validate()
+}
+
+// newPushParam 构造推送参数
+//
+//	audience 为nil时不设置推送目标，用于按cid群推前创建消息
+func (g *PushClient) newPushParam(o *pushOptions, msgType, scheduleTime int, audience *models.Audience, message *models.Message) (pushParam *models.PushParam, err error) {
+	if err = checkMessage(message); err != nil {
+		return
+	}
+	groupName, err := g.getGroupName(o)
+	if err != nil {
+		return
+	}
+	if audience != nil {
+		if err = audience.Validate(); err != nil {
+			return
+		}
+	}
+	pushMessage, pushChannel, setting, err := g.getPushMessageAndChannel(o.kind, msgType, scheduleTime, message)
+	if err != nil {
+		return
+	}
+	if message.IOS == nil {
+		o.ios.Apply(pushChannel.IOS)
+	}
+	g.truncate(o, pushChannel)
+	pushParam = &models.PushParam{
+		GroupName:   groupName,
+		RequestId:   getRandString(),
+		Setting:     setting,
+		Audience:    audience,
+		PushMessage: pushMessage,
+		PushChannel: pushChannel,
+	}
+	err = pushParam.Validate()
+	return
+}
+
+// doPush 发送推送请求
+//
+//	path 为接口路径，演练模式下只记录请求，不发送
+//	演练模式下同样检查 SafetyLimit，以便演练时就能发现会被拒绝的推送
+func (g *PushClient) doPush(o *pushOptions, path string, pushParam *models.PushParam, fn func(t *transport, appId, token string, param *models.PushParam) (*models.Response, error)) (resp *models.Response, err error) {
+	if err = g.checkSafetyLimit(o, pushParam.Audience); err != nil {
+		return
+	}
+	if dryRun := g.getDryRun(o); dryRun != nil {
+		return dryRun.record("POST", g.AppId+path, pushParam)
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return
+	}
+	return fn(g.getCallTransport(o), g.AppId, token, pushParam)
+}
+
+// getTransport 返回请求API时使用的配置
+//
+//	日志优先使用 AppConfig.Logger，masterSecret 和 appSecret 在日志中隐藏
+//	AppConfig.Tracer 和 AppConfig.Metrics 不为nil时，每次请求都会上报
+func (g *PushClient) getTransport() *transport {
+	t := defaultTransport()
+	if g.IsDebug() {
+		t.debug = true
+	}
+	if g.AppConfig != nil {
+		if g.AppConfig.Logger != nil {
+			t.logger = g.AppConfig.Logger
+		}
+		t.tracer = g.AppConfig.Tracer
+		t.metrics = g.AppConfig.Metrics
+		t.middlewares = g.AppConfig.Middlewares
+		if g.AppConfig.BaseURL != "" {
+			t.baseURL = strings.TrimRight(g.AppConfig.BaseURL, "/") + "/"
+		}
+	}
+	if g.PushConfig != nil {
+		t.appId = g.AppId
+		t.secrets = []string{g.MasterSecret, g.AppSecret}
+	}
+	return t
+}
+
+// getTokenStore 返回token的存储
+func (g *PushClient) getTokenStore() TokenStore {
+	if g.AppConfig != nil && g.AppConfig.TokenStore != nil {
+		return g.AppConfig.TokenStore
+	}
+	return &redisTokenStore{}
+}
+
+// getTokenKey 返回存储token的key
+//
+//	未设置 PushStore.Key 时使用 getui:token:{appId}
+func (g *PushClient) getTokenKey() string {
+	if g.PushStore != nil && g.PushStore.Key != "" {
+		return g.PushStore.Key
+	}
+	return "getui:token:" + g.AppId
+}
+
+// getCallTransport 返回单次推送使用的配置
+//
+//	WithDebug 只对本次推送打开调试模式，WithContext 只用于本次推送的请求
+func (g *PushClient) getCallTransport(o *pushOptions) *transport {
+	t := g.getTransport()
+	if o.debug {
+		t.debug = true
+	}
+	if o.ctx != nil {
+		t.ctx = o.ctx
+	}
+	return t
+}
+
+// truncate 按截断策略截断通知的标题和内容
+//
+//	截断的内容写入日志，设置了 WithTruncateReport 时同时记录到report中
+func (g *PushClient) truncate(o *pushOptions, pushChannel *models.PushChannel) {
+	policy := o.truncate
+	if !o.hasTruncate && g.AppConfig != nil {
+		policy = g.AppConfig.Truncate
+	}
+	items := policy.apply(pushChannel)
+	if len(items) == 0 {
+		return
+	}
+	logger := g.getTransport().logger
+	for _, item := range items {
+		logger.Log(LevelWarn, "通知内容超过厂商长度限制，已截断", F("field", item.Field), F("vendor", item.Vendor), F("limit", item.Limit))
+	}
+	if o.truncateReport != nil {
+		o.truncateReport.add(items)
+	}
+}
+
+// getDryRun 返回演练模式的记录器
+//
+//	优先使用 WithDryRun 设置的值，其次使用 AppConfig.DryRun，为nil时正常发送
+func (g *PushClient) getDryRun(o *pushOptions) *DryRun {
+	if o.dryRun != nil {
+		return o.dryRun
+	}
+	if g.AppConfig != nil {
+		return g.AppConfig.DryRun
+	}
+	return nil
+}
+
 // getIntent 返回android的intent地址
 func getIntent(url string) string {
 	if url == "" {
@@ -969,7 +1725,24 @@ func getRandString() string {
 	return time.Now().Format("2006-01-02-15-04-05")
 }
 
-func getGroupName() string {
+// getGroupName 返回本次推送的任务组名
+//
+//	优先使用 WithGroupName 设置的值，其次使用 AppConfig.GroupNameFunc，最后使用默认值
+func (g *PushClient) getGroupName(o *pushOptions) (groupName string, err error) {
+	switch {
+	case o.groupName != "":
+		groupName = o.groupName
+	case g.AppConfig != nil && g.AppConfig.GroupNameFunc != nil:
+		groupName = g.AppConfig.GroupNameFunc()
+	default:
+		groupName = defaultGroupName()
+	}
+	err = CheckGroupName(groupName)
+	return
+}
+
+// defaultGroupName 默认的任务组名
+func defaultGroupName() string {
 	return fmt.Sprintf("ymzy_%d", time.Now().Year())
 }
 
@@ -984,6 +1757,102 @@ func getSplitCid(cid []string, p, limit int) []string {
 	return list
 }
 
+// getSplitAlias return cut []*models.Alias
+func getSplitAlias(list []*models.Alias, p, limit int) []*models.Alias {
+	offset := (p - 1) * limit
+	end := offset + limit
+	if end > len(list) {
+		end = len(list)
+	}
+	return list[offset:end]
+}
+
+// checkSafetyLimit 推送前预估推送范围
+//
+//	设置了 SafetyLimit 且预估用户数超过该值时，返回 *SafetyLimitError
+//	按cid或别名推送时不检查；只在需要预估时获取token
+func (g *PushClient) checkSafetyLimit(o *pushOptions, audience *models.Audience) error {
+	limit := int64(0)
+	if g.AppConfig != nil {
+		limit = g.AppConfig.SafetyLimit
+	}
+	if o.hasSafetyLimit {
+		limit = o.safetyLimit
+	}
+	if limit <= 0 {
+		return nil
+	}
+	var tags []*models.Tag
+	switch {
+	case audience.IsAll():
+		tags = []*models.Tag{models.TagPhoneType("android", "ios", "harmony")}
+	case audience.FastCustomTag != "":
+		tags = []*models.Tag{models.TagCustom(audience.FastCustomTag)}
+	case len(audience.Tag) > 0:
+		tags = audience.Tag
+	default:
+		return nil
+	}
+	token, err := g.GetToken()
+	if err != nil {
+		return err
+	}
+	resp, err := getUserCount(g.getTransport(), g.AppId, token, tags)
+	if err != nil {
+		return fmt.Errorf("%s 预估推送范围失败: %s", NAME, err.Error())
+	}
+	count := gjson.Get(resp.Data, "user_count").Int()
+	if count > limit {
+		return &SafetyLimitError{Count: count, Limit: limit}
+	}
+	return nil
+}
+
+// checkScheduleTime 校验定时推送时间
+//
+//	必须是当前时间之后，7天之内的时间
+func checkScheduleTime(t time.Time) error {
+	now := time.Now()
+	if !t.After(now) {
+		return &ScheduleTimeError{ScheduleTime: t, Reason: "不能早于当前时间"}
+	}
+	if t.Sub(now) > maxScheduleDuration {
+		return &ScheduleTimeError{ScheduleTime: t, Reason: "必须在7天之内"}
+	}
+	return nil
+}
+
+// getScheduleMillis 校验定时推送时间并返回毫秒时间戳
+func getScheduleMillis(t time.Time) (int, error) {
+	if err := checkScheduleTime(t); err != nil {
+		return 0, err
+	}
+	return int(t.UnixNano() / int64(time.Millisecond)), nil
+}
+
+// ReportDateRange 返回start到end之间的每一天，用于 ReportPushDates 和 ReportUserDates
+//
+//	包含start和end当天；end先转换到start的时区，再按天计算
+//	结束日期不能早于开始日期，一次最多查询31天
+func ReportDateRange(start, end time.Time) (days []time.Time, err error) {
+	loc := start.Location()
+	end = end.In(loc)
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
+	if end.Before(start) {
+		err = errors.New("结束日期早于开始日期")
+		return
+	}
+	if end.After(start.AddDate(0, 0, reportMaxDays-1)) {
+		err = fmt.Errorf("日期范围不能超过%d天", reportMaxDays)
+		return
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return
+}
+
 // getPageCount return pageCount
 func getPageCount(limit, count int) (pageCount int) {
 	if count > 0 && limit > 0 {
@@ -0,0 +1,455 @@
+package getuipush_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestPushArgumentErrors(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const title = "  标题  "
+	payload := func() *models.CustomMessage {
+		return &models.CustomMessage{Title: title, Content: "内容", Url: "/home"}
+	}
+	message := func(v interface{}) *models.Message {
+		return &models.Message{Title: "标题", Body: "内容", Payload: v}
+	}
+	tests := []struct {
+		name    string
+		payload *models.CustomMessage
+		push    func(payload *models.CustomMessage) error
+		wantErr error
+	}{
+		{
+			name: "nil payload",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushSingleByCid(int(getuipush.ArticleMsg), "cid1", payload)
+				return err
+			},
+			wantErr: getuipush.ErrNilPayload,
+		},
+		{
+			name:    "empty payload",
+			payload: &models.CustomMessage{},
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushSingleByCid(int(getuipush.ArticleMsg), "cid1", payload)
+				return err
+			},
+			wantErr: getuipush.ErrEmptyPayload,
+		},
+		{
+			name:    "empty cid",
+			payload: payload(),
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushSingleByCid(int(getuipush.ArticleMsg), "", payload)
+				return err
+			},
+			wantErr: getuipush.ErrEmptyCid,
+		},
+		{
+			name:    "blank cid",
+			payload: payload(),
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushSingleByCid(int(getuipush.ArticleMsg), "  ", payload)
+				return err
+			},
+			wantErr: getuipush.ErrEmptyCid,
+		},
+		{
+			name:    "empty cid list",
+			payload: payload(),
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushListByCid(int(getuipush.ArticleMsg), nil, payload)
+				return err
+			},
+			wantErr: getuipush.ErrEmptyCid,
+		},
+		{
+			name:    "blank cid in list",
+			payload: payload(),
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushListByCid(int(getuipush.ArticleMsg), []string{"cid1", " "}, payload)
+				return err
+			},
+			wantErr: getuipush.ErrEmptyCid,
+		},
+		{
+			name:    "empty alias",
+			payload: payload(),
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushSingleByAlias(int(getuipush.ArticleMsg), "", payload)
+				return err
+			},
+			wantErr: getuipush.ErrEmptyAlias,
+		},
+		{
+			name: "search empty alias",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.SearchCidByAlias("")
+				return err
+			},
+			wantErr: getuipush.ErrEmptyAlias,
+		},
+		{
+			name: "nil message",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), nil)
+				return err
+			},
+			wantErr: getuipush.ErrNilPayload,
+		},
+		{
+			name: "message nil payload",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), message(nil))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyPayload,
+		},
+		{
+			name: "message empty string payload",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), message(""))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyPayload,
+		},
+		{
+			name: "message empty bytes payload",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), message([]byte{}))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyPayload,
+		},
+		{
+			name: "message empty raw message payload",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), message(json.RawMessage{}))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyPayload,
+		},
+		{
+			name: "message blank cid",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids(" "), message("{}"))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyCid,
+		},
+		{
+			name: "message blank alias",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByAliases(" "), message("{}"))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyAlias,
+		},
+		{
+			name: "message empty cid list",
+			push: func(payload *models.CustomMessage) error {
+				_, err := client.PushListMessage(int(getuipush.ArticleMsg), []string{}, message("{}"))
+				return err
+			},
+			wantErr: getuipush.ErrEmptyCid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.push(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.payload != nil && *tt.payload != (models.CustomMessage{}) && tt.payload.Title != title {
+				t.Fatalf("payload.Title = %q, want %q", tt.payload.Title, title)
+			}
+			if n := len(s.Pushes()); n != 0 {
+				t.Fatalf("server received %d pushes, want 0", n)
+			}
+		})
+	}
+}
+
+func TestPushKeepsPayload(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := &models.CustomMessage{Title: "  标题  ", Content: "内容", Url: "/home"}
+	if _, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "cid1", payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Title != "  标题  " {
+		t.Fatalf("payload.Title = %q, want it unchanged", payload.Title)
+	}
+	push := s.LastPush()
+	if push == nil {
+		t.Fatal("server received no push")
+	}
+	if got := push.Param.PushChannel.IOS.Aps.Alert.Title; got != "标题" {
+		t.Fatalf("ios alert title = %q, want %q", got, "标题")
+	}
+}
+
+func TestWithIOS(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "ios", "11000000")
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zero := 0.0
+	opt := getuipush.WithIOS(&models.IOSOptions{ThreadId: "room-1001", RelevanceScore: &zero})
+	if _, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "cid1", &models.CustomMessage{Title: "标题", Content: "内容"}, opt); err != nil {
+		t.Fatal(err)
+	}
+	aps := s.LastPush().Param.PushChannel.IOS.Aps
+	if aps.ThreadId != "room-1001" {
+		t.Fatalf("thread-id = %q, want room-1001", aps.ThreadId)
+	}
+	if aps.RelevanceScore == nil || *aps.RelevanceScore != 0 {
+		t.Fatalf("relevance-score = %v, want explicit 0", aps.RelevanceScore)
+	}
+
+	// Message.IOS 优先于 WithIOS
+	message := &models.Message{Title: "标题", Body: "内容", Payload: "{}", IOS: &models.IOSOptions{ThreadId: "room-2002"}}
+	if _, err = client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), message, opt); err != nil {
+		t.Fatal(err)
+	}
+	aps = s.LastPush().Param.PushChannel.IOS.Aps
+	if aps.ThreadId != "room-2002" || aps.RelevanceScore != nil {
+		t.Fatalf("aps = %+v, want Message.IOS only", aps)
+	}
+
+	score := 1.5
+	_, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "cid1", &models.CustomMessage{Title: "标题"}, getuipush.WithIOS(&models.IOSOptions{RelevanceScore: &score}))
+	var ve *models.ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("error = %v, want *models.ValidationError", err)
+	}
+}
+
+func TestSafetyLimit(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	s.AddDevice("cid2", "ios", "11000000")
+	s.AddDevice("cid3", "harmony", "12000000")
+	payload := &models.CustomMessage{Title: "标题", Content: "内容"}
+
+	tests := []struct {
+		name      string
+		appLimit  int64
+		opts      []getuipush.PushOption
+		dryRun    bool
+		single    bool
+		wantCount int64 //期望 *SafetyLimitError 中的预估用户数，为0时期望推送成功
+		wantLimit int64
+	}{
+		{name: "no limit"},
+		{name: "under app limit", appLimit: 3},
+		{name: "over app limit", appLimit: 2, wantCount: 3, wantLimit: 2},
+		{name: "option raises app limit", appLimit: 2, opts: []getuipush.PushOption{getuipush.WithSafetyLimit(10)}},
+		{name: "option lowers app limit", appLimit: 10, opts: []getuipush.PushOption{getuipush.WithSafetyLimit(1)}, wantCount: 3, wantLimit: 1},
+		{name: "option disables app limit", appLimit: 2, opts: []getuipush.PushOption{getuipush.WithSafetyLimit(0)}},
+		{name: "dry run under limit", appLimit: 3, dryRun: true},
+		{name: "dry run over limit", appLimit: 2, dryRun: true, wantCount: 3, wantLimit: 2},
+		{name: "single push is not checked", appLimit: 1, single: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := s.NewClient(&getuipush.AppConfig{SafetyLimit: tt.appLimit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dryRun := getuipush.NewDryRun()
+			opts := tt.opts
+			if tt.dryRun {
+				opts = append(opts, getuipush.WithDryRun(dryRun))
+			}
+			before := len(s.Pushes())
+			if tt.single {
+				_, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "cid1", payload, opts...)
+			} else {
+				_, err = client.PushAll(int(getuipush.ArticleMsg), 0, payload, opts...)
+			}
+			pushed := len(s.Pushes()) - before + len(dryRun.Requests())
+
+			if tt.wantCount == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pushed != 1 {
+					t.Fatalf("pushed %d times, want 1", pushed)
+				}
+				return
+			}
+			var limitErr *getuipush.SafetyLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("error = %v, want *SafetyLimitError", err)
+			}
+			if limitErr.Count != tt.wantCount || limitErr.Limit != tt.wantLimit {
+				t.Fatalf("SafetyLimitError = %+v, want count %d limit %d", limitErr, tt.wantCount, tt.wantLimit)
+			}
+			if pushed != 0 {
+				t.Fatalf("pushed %d times, want 0", pushed)
+			}
+		})
+	}
+}
+
+func TestPreviewAudience(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	s.AddDevice("cid2", "ios", "11000000")
+	s.AddDevice("cid3", "harmony", "12000000")
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := client.PreviewAudience([]*models.Tag{models.TagRegion("11000000")})
+	if err != nil || count != 2 {
+		t.Fatalf("PreviewAudience = %d, %v, want 2", count, err)
+	}
+}
+
+func TestPushListPages(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cids := make([]string, 2500)
+	for i := range cids {
+		cids[i] = fmt.Sprintf("cid-%04d", i)
+	}
+	data, err := client.PushListByCid(int(getuipush.ArticleMsg), cids, &models.CustomMessage{Title: "标题", Content: "内容"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 {
+		t.Fatalf("len(data) = %d, want 3 pages", len(data))
+	}
+
+	// 每一页都是校验过的完整参数，使用同一个taskid
+	taskId, total := "", 0
+	for _, push := range s.Pushes() {
+		if push.ListParam == nil {
+			continue
+		}
+		if err := push.ListParam.Validate(); err != nil {
+			t.Fatalf("page %d: %v", total/1000, err)
+		}
+		if taskId == "" {
+			taskId = push.ListParam.TaskId
+		} else if push.ListParam.TaskId != taskId {
+			t.Fatalf("taskid = %s, want %s", push.ListParam.TaskId, taskId)
+		}
+		total += len(push.ListParam.Audience.Cid)
+	}
+	if total != len(cids) {
+		t.Fatalf("pushed %d cids, want %d", total, len(cids))
+	}
+}
+
+func TestAliasArgumentErrors(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	var paths []string
+	client, err := s.NewClient(&getuipush.AppConfig{Middlewares: []getuipush.Middleware{recordPaths(&paths)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{name: "BindAlias nil", want: getuipush.ErrEmptyCid, call: func() error {
+			_, err := client.BindAlias(nil)
+			return err
+		}},
+		{name: "BindAlias blank cid", want: getuipush.ErrEmptyCid, call: func() error {
+			_, err := client.BindAlias(&models.Alias{Cid: " ", Alias: "user1"})
+			return err
+		}},
+		{name: "BindAlias blank alias", want: getuipush.ErrEmptyAlias, call: func() error {
+			_, err := client.BindAlias(&models.Alias{Cid: "cid1", Alias: " "})
+			return err
+		}},
+		{name: "UnBindAlias nil", want: getuipush.ErrEmptyCid, call: func() error {
+			_, err := client.UnBindAlias(nil)
+			return err
+		}},
+		{name: "UnBindAlias blank alias", want: getuipush.ErrEmptyAlias, call: func() error {
+			_, err := client.UnBindAlias(&models.Alias{Cid: "cid1"})
+			return err
+		}},
+		{name: "UnBindAllAlias empty", want: getuipush.ErrEmptyAlias, call: func() error {
+			_, err := client.UnBindAllAlias("")
+			return err
+		}},
+		{name: "UnBindAllAlias blank", want: getuipush.ErrEmptyAlias, call: func() error {
+			_, err := client.UnBindAllAlias("  ")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	// 参数错误时不获取token，也不请求接口
+	if len(paths) != 0 {
+		t.Fatalf("invalid arguments sent requests: %v", paths)
+	}
+}
+
+func TestUnBindAllAlias(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	var paths []string
+	client, err := s.NewClient(&getuipush.AppConfig{Middlewares: []getuipush.Middleware{recordPaths(&paths)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alias := "用户_1"
+	if _, err = client.BindAlias(&models.Alias{Cid: "cid1", Alias: alias}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.UnBindAllAlias("  " + alias + " "); err != nil {
+		t.Fatal(err)
+	}
+	if want := getuitest.AppId + "/user/alias/%E7%94%A8%E6%88%B7_1"; paths[len(paths)-1] != want {
+		t.Fatalf("path = %q, want %q", paths[len(paths)-1], want)
+	}
+	if cids := s.CidsByAlias(alias); len(cids) != 0 {
+		t.Fatalf("alias is still bound to %v", cids)
+	}
+}
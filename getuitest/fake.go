@@ -0,0 +1,742 @@
+package getuitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/models"
+)
+
+// FakePush Fake 收到的一次推送
+type FakePush struct {
+	Method       string                 //调用的方法，如 PushAll
+	TaskId       string                 //返回的任务id
+	MsgType      int                    //消息类型
+	ScheduleTime int                    //定时推送的毫秒时间戳，0表示立即推送
+	Audience     *models.Audience       //推送目标
+	ClientTypes  []getuipush.ClientType //按客户端推送时的客户端类型
+	Payload      *models.CustomMessage  //消息内容
+	Message      *models.Message        //PushMessage 和 PushListMessage 的消息内容
+	Opts         []getuipush.PushOption //推送选项
+	Time         time.Time              //推送时间
+
+	Options     *getuipush.PushOptions //由 Opts 解析的推送选项
+	GroupName   string                 //任务组名，WithGroupName
+	ScheduleAt  time.Time              //定时推送的时间，零值表示立即推送；包括 PushMessage 的 WithScheduleTime
+	SafetyLimit int64                  //预估用户数上限，WithSafetyLimit
+	DryRun      bool                   //是否使用了 WithDryRun
+}
+
+// Fake 内存中的 getuipush.PushService
+//
+//	不发送请求也不需要redis，用于业务代码的单元测试
+//	只记录调用和维护别名、标签，不校验推送参数；需要校验时请使用 Server
+//	WithScheduleTime 的规则与 PushClient 相同，见 checkScheduleOption
+type Fake struct {
+	mu        sync.Mutex
+	aliases   map[string]string   //cid -> alias
+	tags      map[string][]string //cid -> 自定义标签
+	pushes    []*FakePush
+	schedules map[string]*models.ScheduleTask
+	errors    map[string]error
+	userCount int64
+	seq       int
+}
+
+var _ getuipush.PushService = (*Fake)(nil)
+
+// NewFake 返回内存中的 PushService
+func NewFake() *Fake {
+	return &Fake{
+		aliases:   make(map[string]string),
+		tags:      make(map[string][]string),
+		schedules: make(map[string]*models.ScheduleTask),
+		errors:    make(map[string]error),
+	}
+}
+
+// SetError 让方法返回指定的错误
+//
+//	method 为方法名，如 "PushAll"，"*" 表示所有方法；err 为nil时取消
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = err
+}
+
+// SetUserCount 设置 GetUserCount 和 PreviewAudience 返回的用户数
+func (f *Fake) SetUserCount(count int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.userCount = count
+}
+
+// Pushes 返回收到的所有推送
+func (f *Fake) Pushes() []*FakePush {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*FakePush(nil), f.pushes...)
+}
+
+// LastPush 返回最后一次推送，没有时返回nil
+func (f *Fake) LastPush() *FakePush {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pushes) == 0 {
+		return nil
+	}
+	return f.pushes[len(f.pushes)-1]
+}
+
+// Alias 返回cid绑定的别名
+func (f *Fake) Alias(cid string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.aliases[cid]
+}
+
+// Tags 返回cid绑定的自定义标签
+func (f *Fake) Tags(cid string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.tags[cid]...)
+}
+
+// Reset 清空所有数据和设置的错误
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.aliases = make(map[string]string)
+	f.tags = make(map[string][]string)
+	f.pushes = nil
+	f.schedules = make(map[string]*models.ScheduleTask)
+	f.errors = make(map[string]error)
+	f.userCount = 0
+}
+
+// getError 返回方法设置的错误，需持有锁
+func (f *Fake) getError(method string) error {
+	if err, ok := f.errors[method]; ok {
+		return err
+	}
+	return f.errors["*"]
+}
+
+// addPush 记录一次推送
+func (f *Fake) addPush(push *FakePush) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError(push.Method); err != nil {
+		return nil, err
+	}
+	o := getuipush.ResolvePushOptions(push.Opts...)
+	if !o.ScheduleTime.IsZero() {
+		if err := checkScheduleOption(push); err != nil {
+			return nil, err
+		}
+		push.ScheduleTime = millis(o.ScheduleTime)
+	}
+	push.Options = o
+	push.GroupName = o.GroupName
+	push.SafetyLimit = o.SafetyLimit
+	push.DryRun = o.DryRun != nil
+	if push.ScheduleTime > 0 {
+		push.ScheduleAt = time.Unix(0, int64(push.ScheduleTime)*int64(time.Millisecond))
+	}
+	f.seq++
+	push.TaskId = fmt.Sprintf("fake-task-%d", f.seq)
+	push.Time = time.Now()
+	f.pushes = append(f.pushes, push)
+	if push.ScheduleTime > 0 {
+		f.schedules[push.TaskId] = &models.ScheduleTask{
+			CreateTime: strconv.FormatInt(push.Time.UnixNano()/1e6, 10),
+			Status:     "pending",
+			PushTime:   strconv.Itoa(push.ScheduleTime),
+		}
+	}
+	return newResponse(map[string]string{"taskid": push.TaskId}, push.TaskId), nil
+}
+
+// checkScheduleOption 与 PushClient 相同的 WithScheduleTime 规则
+//
+//	只有 PushMessage 使用，单推、voip和静默消息不支持定时推送
+func checkScheduleOption(push *FakePush) error {
+	switch push.Method {
+	case "PushMessage":
+		if push.Audience != nil && (push.Audience.Cid != nil || push.Audience.Alias != nil) {
+			return getuipush.ErrScheduleNotSupported
+		}
+		return nil
+	case "PushVoIP", "PushSilent":
+		return getuipush.ErrScheduleNotSupported
+	default:
+		return getuipush.ErrScheduleOption
+	}
+}
+
+// call 检查方法设置的错误
+func (f *Fake) call(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.getError(method)
+}
+
+// newResponse 返回成功的Response，data序列化为json字串
+func newResponse(data interface{}, taskId string) *models.Response {
+	resp := &models.Response{
+		Code:   0,
+		Msg:    "success",
+		TaskId: taskId,
+	}
+	if data != nil {
+		b, _ := json.Marshal(data)
+		resp.Data = string(b)
+	}
+	return resp
+}
+
+// millis 返回毫秒时间戳
+func millis(t time.Time) int {
+	return int(t.UnixNano() / 1e6)
+}
+
+/*
+===============================================================
+推送
+===============================================================
+*/
+
+// PushAll 推送给所有用户
+func (f *Fake) PushAll(msgType, scheduleTime int, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAll", MsgType: msgType, ScheduleTime: scheduleTime, Audience: models.All(), Payload: payload, Opts: opts})
+}
+
+// PushAllAt 定时推送给所有用户
+func (f *Fake) PushAllAt(msgType int, scheduleTime time.Time, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllAt", MsgType: msgType, ScheduleTime: millis(scheduleTime), Audience: models.All(), Payload: payload, Opts: opts})
+}
+
+// PushAllByClient 按客户端类型推送
+func (f *Fake) PushAllByClient(msgType, scheduleTime int, clientType getuipush.ClientType, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllByClient", MsgType: msgType, ScheduleTime: scheduleTime, ClientTypes: []getuipush.ClientType{clientType}, Payload: payload, Opts: opts})
+}
+
+// PushAllByClients 按多个客户端类型推送
+func (f *Fake) PushAllByClients(msgType, scheduleTime int, clientTypes []getuipush.ClientType, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllByClients", MsgType: msgType, ScheduleTime: scheduleTime, ClientTypes: clientTypes, Payload: payload, Opts: opts})
+}
+
+// PushAllByClientAt 按客户端类型定时推送
+func (f *Fake) PushAllByClientAt(msgType int, scheduleTime time.Time, clientType getuipush.ClientType, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllByClientAt", MsgType: msgType, ScheduleTime: millis(scheduleTime), ClientTypes: []getuipush.ClientType{clientType}, Payload: payload, Opts: opts})
+}
+
+// PushSingleByCid 按cid推送
+func (f *Fake) PushSingleByCid(msgType int, cid string, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushSingleByCid", MsgType: msgType, Audience: models.ByCids(cid), Payload: payload, Opts: opts})
+}
+
+// PushSingleByAlias 按别名推送
+func (f *Fake) PushSingleByAlias(msgType int, alias string, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushSingleByAlias", MsgType: msgType, Audience: models.ByAliases(alias), Payload: payload, Opts: opts})
+}
+
+// PushListByCid 按cid群推
+func (f *Fake) PushListByCid(msgType int, cid []string, payload *models.CustomMessage, opts ...getuipush.PushOption) ([]*models.Response, error) {
+	resp, err := f.addPush(&FakePush{Method: "PushListByCid", MsgType: msgType, Audience: models.ByCids(cid...), Payload: payload, Opts: opts})
+	if err != nil {
+		return nil, err
+	}
+	return []*models.Response{resp}, nil
+}
+
+// PushAllByCustomTag 按自定义标签推送
+func (f *Fake) PushAllByCustomTag(msgType, scheduleTime int, customTag []string, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllByCustomTag", MsgType: msgType, ScheduleTime: scheduleTime, Audience: models.ByTags(models.TagCustom(customTag...)), Payload: payload, Opts: opts})
+}
+
+// PushAllByCustomTagAt 按自定义标签定时推送
+func (f *Fake) PushAllByCustomTagAt(msgType int, scheduleTime time.Time, customTag []string, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllByCustomTagAt", MsgType: msgType, ScheduleTime: millis(scheduleTime), Audience: models.ByTags(models.TagCustom(customTag...)), Payload: payload, Opts: opts})
+}
+
+// PushAllByLogicTags 按标签表达式推送
+func (f *Fake) PushAllByLogicTags(msgType, scheduleTime int, tags []*models.Tag, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAllByLogicTags", MsgType: msgType, ScheduleTime: scheduleTime, Audience: models.ByTags(tags...), Payload: payload, Opts: opts})
+}
+
+// PushAppByFastCustomTag 使用标签快速推送
+func (f *Fake) PushAppByFastCustomTag(msgType, scheduleTime int, tag string, payload *models.CustomMessage, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushAppByFastCustomTag", MsgType: msgType, ScheduleTime: scheduleTime, Audience: models.ByFastTag(tag), Payload: payload, Opts: opts})
+}
+
+// PushMessage 推送自定义内容的消息
+//
+//	WithScheduleTime 设置的时间记录在 ScheduleTime 和 ScheduleAt 中，单推时返回 getuipush.ErrScheduleNotSupported
+func (f *Fake) PushMessage(msgType int, audience *models.Audience, message *models.Message, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushMessage", MsgType: msgType, Audience: audience, Message: message, Opts: opts})
+}
+
+// PushListMessage 按cid群推自定义内容的消息
+func (f *Fake) PushListMessage(msgType int, cid []string, message *models.Message, opts ...getuipush.PushOption) ([]*models.Response, error) {
+	resp, err := f.addPush(&FakePush{Method: "PushListMessage", MsgType: msgType, Audience: models.ByCids(cid...), Message: message, Opts: opts})
+	if err != nil {
+		return nil, err
+	}
+	return []*models.Response{resp}, nil
+}
+
+// PushVoIP 推送voip消息
+func (f *Fake) PushVoIP(msgType int, audience *models.Audience, message *models.Message, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushVoIP", MsgType: msgType, Audience: audience, Message: message, Opts: opts})
+}
+
+// PushSilent 推送静默消息
+func (f *Fake) PushSilent(audience *models.Audience, message *models.Message, opts ...getuipush.PushOption) (*models.Response, error) {
+	return f.addPush(&FakePush{Method: "PushSilent", Audience: audience, Message: message, Opts: opts})
+}
+
+/*
+===============================================================
+任务
+===============================================================
+*/
+
+// StopTask 停止任务
+func (f *Fake) StopTask(taskId string) (*models.Response, error) {
+	if err := f.call("StopTask"); err != nil {
+		return nil, err
+	}
+	return newResponse(nil, ""), nil
+}
+
+// GetScheduleTask 查询定时任务
+func (f *Fake) GetScheduleTask(taskId string) (*models.ScheduleTask, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("GetScheduleTask"); err != nil {
+		return nil, err
+	}
+	task, ok := f.schedules[taskId]
+	if !ok {
+		return nil, fmt.Errorf("定时任务不存在: %s", taskId)
+	}
+	return task, nil
+}
+
+// GetScheduleTasks 查询多个定时任务
+func (f *Fake) GetScheduleTasks(taskIds ...string) (map[string]*models.ScheduleTask, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("GetScheduleTasks"); err != nil {
+		return nil, err
+	}
+	data := make(map[string]*models.ScheduleTask)
+	for _, taskId := range taskIds {
+		if task, ok := f.schedules[taskId]; ok {
+			data[taskId] = task
+		}
+	}
+	return data, nil
+}
+
+// CancelScheduleTask 取消定时任务
+func (f *Fake) CancelScheduleTask(taskId string) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("CancelScheduleTask"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.schedules[taskId]; !ok {
+		return nil, fmt.Errorf("定时任务不存在: %s", taskId)
+	}
+	delete(f.schedules, taskId)
+	return newResponse(nil, ""), nil
+}
+
+/*
+===============================================================
+别名和标签
+===============================================================
+*/
+
+// BindAlias 绑定别名
+func (f *Fake) BindAlias(param *models.Alias) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("BindAlias"); err != nil {
+		return nil, err
+	}
+	f.aliases[param.Cid] = param.Alias
+	return newResponse(nil, ""), nil
+}
+
+// UnBindAlias 解绑别名
+func (f *Fake) UnBindAlias(param *models.Alias) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("UnBindAlias"); err != nil {
+		return nil, err
+	}
+	if f.aliases[param.Cid] == param.Alias {
+		delete(f.aliases, param.Cid)
+	}
+	return newResponse(nil, ""), nil
+}
+
+// BindAliases 批量绑定别名
+func (f *Fake) BindAliases(list []*models.Alias) (*models.AliasBatchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("BindAliases"); err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		f.aliases[item.Cid] = item.Alias
+	}
+	return &models.AliasBatchResult{Responses: []*models.Response{newResponse(nil, "")}}, nil
+}
+
+// UnBindAliases 批量解绑别名
+func (f *Fake) UnBindAliases(list []*models.Alias) (*models.AliasBatchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("UnBindAliases"); err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		if f.aliases[item.Cid] == item.Alias {
+			delete(f.aliases, item.Cid)
+		}
+	}
+	return &models.AliasBatchResult{Responses: []*models.Response{newResponse(nil, "")}}, nil
+}
+
+// UnBindAllAlias 解绑别名的所有cid
+func (f *Fake) UnBindAllAlias(alias string) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("UnBindAllAlias"); err != nil {
+		return nil, err
+	}
+	for cid, a := range f.aliases {
+		if a == alias {
+			delete(f.aliases, cid)
+		}
+	}
+	return newResponse(nil, ""), nil
+}
+
+// BindTags 一个cid绑定多个标签
+func (f *Fake) BindTags(cid string, param *models.CustomTagsParam) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("BindTags"); err != nil {
+		return nil, err
+	}
+	f.tags[cid] = append([]string(nil), param.CustomTag...)
+	return newResponse(nil, ""), nil
+}
+
+// BindTagToCids 一批cid绑定一个标签
+func (f *Fake) BindTagToCids(tag string, cid []string) (*models.TagBatchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("BindTagToCids"); err != nil {
+		return nil, err
+	}
+	for _, c := range cid {
+		if !hasTag(f.tags[c], tag) {
+			f.tags[c] = append(f.tags[c], tag)
+		}
+	}
+	return &models.TagBatchResult{Responses: []*models.Response{newResponse(nil, "")}}, nil
+}
+
+// UnBindTagFromCids 一批cid解绑一个标签
+func (f *Fake) UnBindTagFromCids(tag string, cid []string) (*models.TagBatchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("UnBindTagFromCids"); err != nil {
+		return nil, err
+	}
+	for _, c := range cid {
+		tags := f.tags[c][:0]
+		for _, t := range f.tags[c] {
+			if t != tag {
+				tags = append(tags, t)
+			}
+		}
+		f.tags[c] = tags
+	}
+	return &models.TagBatchResult{Responses: []*models.Response{newResponse(nil, "")}}, nil
+}
+
+/*
+===============================================================
+用户查询
+===============================================================
+*/
+
+// SearchTags 查询cid的标签
+func (f *Fake) SearchTags(cid string) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("SearchTags"); err != nil {
+		return nil, err
+	}
+	return newResponse(map[string][]string{cid: f.tags[cid]}, ""), nil
+}
+
+// SearchStatus 查询用户状态，总是返回在线
+func (f *Fake) SearchStatus(cid string) (*models.Response, error) {
+	if err := f.call("SearchStatus"); err != nil {
+		return nil, err
+	}
+	return newResponse(map[string]map[string]string{
+		cid: {"status": "online"},
+	}, ""), nil
+}
+
+// SearchUser 查询用户信息
+func (f *Fake) SearchUser(cid string) (*models.Response, error) {
+	if err := f.call("SearchUser"); err != nil {
+		return nil, err
+	}
+	return newResponse(map[string]interface{}{
+		"validCids": map[string]map[string]string{cid: {"client_app_id": AppId}},
+	}, ""), nil
+}
+
+// SearchAliasByCid 按cid查询别名
+func (f *Fake) SearchAliasByCid(cid string) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("SearchAliasByCid"); err != nil {
+		return nil, err
+	}
+	alias, ok := f.aliases[cid]
+	if !ok {
+		return nil, fmt.Errorf("cid未绑定别名: %s", cid)
+	}
+	return newResponse(map[string]string{"alias": alias}, ""), nil
+}
+
+// SearchCidByAlias 按别名查询cid
+func (f *Fake) SearchCidByAlias(alias string) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("SearchCidByAlias"); err != nil {
+		return nil, err
+	}
+	var cids []string
+	for cid, a := range f.aliases {
+		if a == alias {
+			cids = append(cids, cid)
+		}
+	}
+	if len(cids) == 0 {
+		return nil, fmt.Errorf("别名未绑定cid: %s", alias)
+	}
+	sort.Strings(cids)
+	return newResponse(map[string][]string{"cid": cids}, ""), nil
+}
+
+// GetUserCount 返回 SetUserCount 设置的用户数
+func (f *Fake) GetUserCount(tags []*models.Tag) (*models.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("GetUserCount"); err != nil {
+		return nil, err
+	}
+	return newResponse(map[string]int64{"user_count": f.userCount}, ""), nil
+}
+
+// PreviewAudience 返回 SetUserCount 设置的用户数
+func (f *Fake) PreviewAudience(tags []*models.Tag) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("PreviewAudience"); err != nil {
+		return 0, err
+	}
+	return f.userCount, nil
+}
+
+/*
+===============================================================
+统计
+===============================================================
+*/
+
+// SearchTaskDetailByCid 查询某任务下某cid的推送详情
+func (f *Fake) SearchTaskDetailByCid(cid, taskId string) (*models.TaskDetailResp, error) {
+	if err := f.call("SearchTaskDetailByCid"); err != nil {
+		return nil, err
+	}
+	return &models.TaskDetailResp{Msg: "success"}, nil
+}
+
+// ReportPushTask 查询任务的推送数据
+func (f *Fake) ReportPushTask(taskId string) (*models.Response, error) {
+	if err := f.call("ReportPushTask"); err != nil {
+		return nil, err
+	}
+	data, err := f.ReportPushTasks(taskId)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(data, ""), nil
+}
+
+// ReportPushTasks 查询多个任务的推送数据
+//
+//	按cid和别名推送时以目标数计算，其他推送以 SetUserCount 设置的用户数计算
+func (f *Fake) ReportPushTasks(taskIds ...string) (map[string]*models.PushReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("ReportPushTasks"); err != nil {
+		return nil, err
+	}
+	data := make(map[string]*models.PushReport)
+	for _, taskId := range taskIds {
+		for _, push := range f.pushes {
+			if push.TaskId == taskId {
+				data[taskId] = f.report([]*FakePush{push})
+			}
+		}
+	}
+	return data, nil
+}
+
+// ReportPushGroup 查询任务组的推送数据，返回 WithGroupName 为 groupName 的推送合计
+func (f *Fake) ReportPushGroup(groupName string) (*models.PushReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("ReportPushGroup"); err != nil {
+		return nil, err
+	}
+	var pushes []*FakePush
+	for _, push := range f.pushes {
+		if push.GroupName == groupName {
+			pushes = append(pushes, push)
+		}
+	}
+	return f.report(pushes), nil
+}
+
+// ReportPushGroups 查询多个任务组的推送数据
+func (f *Fake) ReportPushGroups(groupNames ...string) (map[string]*models.PushReport, error) {
+	data := make(map[string]*models.PushReport)
+	for _, groupName := range groupNames {
+		report, err := f.ReportPushGroup(groupName)
+		if err != nil {
+			return nil, err
+		}
+		data[groupName] = report
+	}
+	return data, nil
+}
+
+// ReportPushDate 查询某天的推送数据
+func (f *Fake) ReportPushDate(date time.Time) (*models.PushReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("ReportPushDate"); err != nil {
+		return nil, err
+	}
+	day := date.Format("2006-01-02")
+	var pushes []*FakePush
+	for _, push := range f.pushes {
+		if push.Time.Format("2006-01-02") == day {
+			pushes = append(pushes, push)
+		}
+	}
+	return f.report(pushes), nil
+}
+
+// ReportPushDates 查询多天的推送数据
+func (f *Fake) ReportPushDates(start, end time.Time) (map[string]*models.PushReport, error) {
+	days, err := getuipush.ReportDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]*models.PushReport)
+	for _, d := range days {
+		report, err := f.ReportPushDate(d)
+		if err != nil {
+			return nil, err
+		}
+		data[d.Format("2006-01-02")] = report
+	}
+	return data, nil
+}
+
+// ReportUserDate 查询某天的用户数据，用户数为 SetUserCount 设置的值
+func (f *Fake) ReportUserDate(date time.Time) (*models.UserReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("ReportUserDate"); err != nil {
+		return nil, err
+	}
+	return &models.UserReport{
+		AccumulativeNum: f.userCount,
+		ActiveNum:       f.userCount,
+		OnlineNum:       f.userCount,
+	}, nil
+}
+
+// ReportUserDates 查询多天的用户数据
+func (f *Fake) ReportUserDates(start, end time.Time) (map[string]*models.UserReport, error) {
+	days, err := getuipush.ReportDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]*models.UserReport)
+	for _, d := range days {
+		report, err := f.ReportUserDate(d)
+		if err != nil {
+			return nil, err
+		}
+		data[d.Format("2006-01-02")] = report
+	}
+	return data, nil
+}
+
+// ReportOnlineUser 查询在线用户数，用户数为 SetUserCount 设置的值
+func (f *Fake) ReportOnlineUser() (map[string]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.getError("ReportOnlineUser"); err != nil {
+		return nil, err
+	}
+	hour := time.Now().Truncate(time.Hour).UnixNano() / 1e6
+	return map[string]int64{strconv.FormatInt(hour, 10): f.userCount}, nil
+}
+
+// report 汇总推送数据，需持有锁
+func (f *Fake) report(pushes []*FakePush) *models.PushReport {
+	total := &models.ReportStat{}
+	for _, push := range pushes {
+		n := f.userCount
+		if push.Audience != nil && len(push.Audience.Cid) > 0 {
+			n = int64(len(push.Audience.Cid))
+		} else if push.Audience != nil && len(push.Audience.Alias) > 0 {
+			n = int64(len(push.Audience.Alias))
+		}
+		total.Add(&models.ReportStat{MsgNum: n, TargetNum: n, ReceiveNum: n, DisplayNum: n})
+	}
+	return &models.PushReport{
+		Total:        total,
+		Detail:       map[string]*models.ReportStat{"gt": total},
+		ActionCntMap: map[string]int64{},
+	}
+}
@@ -0,0 +1,216 @@
+package getuitest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestFakePushOptions(t *testing.T) {
+	f := NewFake()
+	at := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	dryRun := getuipush.NewDryRun()
+	policy := &getuipush.TruncatePolicy{Ellipsis: "…"}
+
+	tests := []struct {
+		name        string
+		push        func() error
+		groupName   string
+		scheduleAt  time.Time
+		safetyLimit int64
+		dryRun      bool
+	}{
+		{
+			name: "no options",
+			push: func() error {
+				_, err := f.PushSingleByCid(int(getuipush.InstantMsg), "cid1", testPayload)
+				return err
+			},
+		},
+		{
+			name: "group name and safety limit",
+			push: func() error {
+				_, err := f.PushAll(int(getuipush.ArticleMsg), 0, testPayload, getuipush.WithGroupName("spring"), getuipush.WithSafetyLimit(100))
+				return err
+			},
+			groupName:   "spring",
+			safetyLimit: 100,
+		},
+		{
+			name: "schedule time argument",
+			push: func() error {
+				_, err := f.PushAllAt(int(getuipush.ArticleMsg), at, testPayload)
+				return err
+			},
+			scheduleAt: at,
+		},
+		{
+			name: "schedule time option",
+			push: func() error {
+				_, err := f.PushMessage(int(getuipush.ArticleMsg), models.All(), &models.Message{Payload: "x"}, getuipush.WithScheduleTime(at))
+				return err
+			},
+			scheduleAt: at,
+		},
+		{
+			name: "dry run and truncate",
+			push: func() error {
+				_, err := f.PushListByCid(int(getuipush.ArticleMsg), []string{"cid1"}, testPayload, getuipush.WithDryRun(dryRun), getuipush.WithTruncate(policy))
+				return err
+			},
+			dryRun: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.push(); err != nil {
+				t.Fatal(err)
+			}
+			push := f.LastPush()
+			if push.GroupName != tt.groupName {
+				t.Errorf("GroupName = %q, want %q", push.GroupName, tt.groupName)
+			}
+			if !push.ScheduleAt.Equal(tt.scheduleAt) {
+				t.Errorf("ScheduleAt = %v, want %v", push.ScheduleAt, tt.scheduleAt)
+			}
+			if push.SafetyLimit != tt.safetyLimit {
+				t.Errorf("SafetyLimit = %d, want %d", push.SafetyLimit, tt.safetyLimit)
+			}
+			if push.DryRun != tt.dryRun {
+				t.Errorf("DryRun = %v, want %v", push.DryRun, tt.dryRun)
+			}
+			if push.Options == nil {
+				t.Fatal("Options is nil")
+			}
+		})
+	}
+	if got := f.LastPush().Options.Truncate; got != policy {
+		t.Errorf("Options.Truncate = %v, want %v", got, policy)
+	}
+}
+
+func TestFakeSchedule(t *testing.T) {
+	f := NewFake()
+	resp, err := f.PushMessage(int(getuipush.ArticleMsg), models.All(), &models.Message{Payload: "x"}, getuipush.WithScheduleTime(time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := f.GetScheduleTask(resp.TaskId)
+	if err != nil || task.Status != "pending" {
+		t.Fatalf("GetScheduleTask = %v, %v", task, err)
+	}
+	if _, err = f.CancelScheduleTask(resp.TaskId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.GetScheduleTask(resp.TaskId); err == nil {
+		t.Fatal("GetScheduleTask after cancel should fail")
+	}
+	if _, err = f.CancelScheduleTask(resp.TaskId); err == nil {
+		t.Fatal("second cancel should fail")
+	}
+}
+
+func TestFakeSetError(t *testing.T) {
+	f := NewFake()
+	errAll := errors.New("all")
+	errPush := errors.New("push")
+	f.SetError("*", errAll)
+	f.SetError("PushAll", errPush)
+
+	if _, err := f.PushAll(int(getuipush.ArticleMsg), 0, testPayload); err != errPush {
+		t.Fatalf("PushAll error = %v, want %v", err, errPush)
+	}
+	if _, err := f.SearchStatus("cid1"); err != errAll {
+		t.Fatalf("SearchStatus error = %v, want %v", err, errAll)
+	}
+	if len(f.Pushes()) != 0 {
+		t.Fatal("failed pushes should not be recorded")
+	}
+
+	f.SetError("*", nil)
+	if _, err := f.SearchStatus("cid1"); err != nil {
+		t.Fatalf("SearchStatus error: %v", err)
+	}
+	f.SetError("PushAll", nil)
+	if _, err := f.PushAll(int(getuipush.ArticleMsg), 0, testPayload); err != nil {
+		t.Fatalf("PushAll error: %v", err)
+	}
+}
+
+func TestFakeAliasAndTags(t *testing.T) {
+	f := NewFake()
+	if _, err := f.BindAliases([]*models.Alias{{Cid: "cid1", Alias: "user1"}, {Cid: "cid2", Alias: "user1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if f.Alias("cid1") != "user1" {
+		t.Fatalf("Alias = %q", f.Alias("cid1"))
+	}
+	resp, err := f.SearchCidByAlias("user1")
+	if err != nil || resp.Data != `{"cid":["cid1","cid2"]}` {
+		t.Fatalf("SearchCidByAlias = %v, %v", resp, err)
+	}
+	if _, err = f.UnBindAllAlias("user1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.SearchCidByAlias("user1"); err == nil {
+		t.Fatal("SearchCidByAlias after UnBindAllAlias should fail")
+	}
+
+	if _, err = f.BindTagToCids("vip", []string{"cid1", "cid2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.BindTagToCids("vip", []string{"cid1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Tags("cid1"); !reflect.DeepEqual(got, []string{"vip"}) {
+		t.Fatalf("Tags = %v, want [vip]", got)
+	}
+	if _, err = f.UnBindTagFromCids("vip", []string{"cid1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Tags("cid1")) != 0 || len(f.Tags("cid2")) != 1 {
+		t.Fatalf("UnBindTagFromCids should only remove cid1")
+	}
+}
+
+func TestFakeReport(t *testing.T) {
+	f := NewFake()
+	f.SetUserCount(10)
+	if _, err := f.PushAll(int(getuipush.ArticleMsg), 0, testPayload, getuipush.WithGroupName("spring")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.PushListByCid(int(getuipush.ArticleMsg), []string{"cid1", "cid2"}, testPayload, getuipush.WithGroupName("autumn")); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := f.PreviewAudience(nil)
+	if err != nil || count != 10 {
+		t.Fatalf("PreviewAudience = %d, %v", count, err)
+	}
+	groups, err := f.ReportPushGroups("spring", "autumn", "winter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"spring": 10, "autumn": 2, "winter": 0}
+	for name, n := range want {
+		if got := groups[name].Total.TargetNum; got != n {
+			t.Errorf("group %s target_num = %d, want %d", name, got, n)
+		}
+	}
+	day, err := f.ReportPushDate(time.Now())
+	if err != nil || day.Total.TargetNum != 12 {
+		t.Fatalf("ReportPushDate = %+v, %v", day, err)
+	}
+
+	f.Reset()
+	if f.LastPush() != nil || f.Alias("cid1") != "" {
+		t.Fatal("Reset should clear pushes")
+	}
+	if count, _ = f.PreviewAudience(nil); count != 0 {
+		t.Fatalf("PreviewAudience after Reset = %d", count)
+	}
+}
@@ -0,0 +1,710 @@
+package getuitest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+// request 测试服务收到的请求
+type request struct {
+	method   string
+	endpoint string   //接口模板，如 /user/status/:cid
+	params   []string //路径参数，按出现顺序
+	body     []byte
+}
+
+// route 接口模板和处理方法
+type route struct {
+	method  string
+	path    []string
+	handler func(s *Server, r *request) (int, string, interface{})
+}
+
+// routes 测试服务支持的接口，":"开头的为路径参数
+//
+//	处理方法在持有锁时调用，返回 code、msg和data
+var routes = []*route{
+	{"POST", []string{"push", "single", "cid"}, (*Server).pushSingle},
+	{"POST", []string{"push", "single", "alias"}, (*Server).pushSingle},
+	{"POST", []string{"push", "all"}, (*Server).pushApp},
+	{"POST", []string{"push", "tag"}, (*Server).pushApp},
+	{"POST", []string{"push", "fast_custom_tag"}, (*Server).pushApp},
+	{"POST", []string{"push", "list", "message"}, (*Server).createMessage},
+	{"POST", []string{"push", "list", "cid"}, (*Server).pushList},
+	{"DELETE", []string{"task", ":taskid"}, (*Server).stopTask},
+	{"GET", []string{"task", "schedule", ":taskid"}, (*Server).getSchedule},
+	{"DELETE", []string{"task", "schedule", ":taskid"}, (*Server).deleteSchedule},
+	{"GET", []string{"task", "detail", ":cid", ":taskid"}, (*Server).taskDetail},
+	{"POST", []string{"user", "alias"}, (*Server).bindAlias},
+	{"DELETE", []string{"user", "alias"}, (*Server).unBindAlias},
+	{"DELETE", []string{"user", "alias", ":alias"}, (*Server).unBindAllAlias},
+	{"GET", []string{"user", "alias", "cid", ":cid"}, (*Server).aliasByCid},
+	{"GET", []string{"user", "cid", "alias", ":alias"}, (*Server).cidByAlias},
+	{"POST", []string{"user", "custom_tag", "cid", ":cid"}, (*Server).bindTags},
+	{"GET", []string{"user", "custom_tag", "cid", ":cid"}, (*Server).searchTags},
+	{"PUT", []string{"user", "custom_tag", "batch", ":tag"}, (*Server).bindTagBatch},
+	{"DELETE", []string{"user", "custom_tag", "batch", ":tag"}, (*Server).unBindTagBatch},
+	{"GET", []string{"user", "status", ":cid"}, (*Server).userStatus},
+	{"GET", []string{"user", "detail", ":cid"}, (*Server).userDetail},
+	{"POST", []string{"user", "count"}, (*Server).userCount},
+	{"GET", []string{"report", "push", "task", ":taskid"}, (*Server).reportTask},
+	{"GET", []string{"report", "push", "task_group", ":group_name"}, (*Server).reportGroup},
+	{"GET", []string{"report", "push", "date", ":date"}, (*Server).reportPushDate},
+	{"GET", []string{"report", "user", "date", ":date"}, (*Server).reportUserDate},
+	{"GET", []string{"report", "online_user"}, (*Server).reportOnlineUser},
+}
+
+// ServeHTTP 处理个推接口请求
+//
+//	路径格式为 /v2/{appId}/...
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.latency
+	s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	if len(segments) < 3 || segments[0] != "v2" {
+		http.NotFound(w, r)
+		return
+	}
+	if segments[1] != AppId {
+		writeJSON(w, CodeParamError, "appId error", nil)
+		return
+	}
+	segments = segments[2:]
+	for i, seg := range segments {
+		if v, err := url.PathUnescape(seg); err == nil {
+			segments[i] = v
+		}
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if segments[0] == "auth" && len(segments) == 1 {
+		if e := s.getError(r.Method, "/auth"); e != nil {
+			writeJSON(w, e.code, e.msg, nil)
+			return
+		}
+		code, msg, data := s.auth(body)
+		writeJSON(w, code, msg, data)
+		return
+	}
+
+	rt, params := matchRoute(r.Method, segments)
+	if rt == nil {
+		http.NotFound(w, r)
+		return
+	}
+	req := &request{
+		method:   r.Method,
+		endpoint: "/" + strings.Join(rt.path, "/"),
+		params:   params,
+		body:     body,
+	}
+	if e := s.getError(req.method, req.endpoint); e != nil {
+		writeJSON(w, e.code, e.msg, nil)
+		return
+	}
+	if !s.tokens[r.Header.Get("token")] {
+		writeJSON(w, CodeTokenInvalid, "token invalid", nil)
+		return
+	}
+	code, msg, data := rt.handler(s, req)
+	writeJSON(w, code, msg, data)
+}
+
+// matchRoute 返回匹配的接口和路径参数
+func matchRoute(method string, segments []string) (*route, []string) {
+	for _, rt := range routes {
+		if rt.method != method || len(rt.path) != len(segments) {
+			continue
+		}
+		var params []string
+		ok := true
+		for i, p := range rt.path {
+			if strings.HasPrefix(p, ":") {
+				params = append(params, segments[i])
+				continue
+			}
+			if p != segments[i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return rt, params
+		}
+	}
+	return nil, nil
+}
+
+// writeJSON 输出个推格式的返回值
+func writeJSON(w http.ResponseWriter, code int, msg string, data interface{}) {
+	if msg == "" && code == CodeSuccess {
+		msg = "success"
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+		"data": data,
+	})
+}
+
+/*
+===============================================================
+鉴权
+===============================================================
+*/
+
+// auth 校验签名并发放token
+func (s *Server) auth(body []byte) (int, string, interface{}) {
+	param := new(models.TokenParam)
+	if err := json.Unmarshal(body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if param.AppKey != AppKey {
+		return CodeParamError, "appkey error", nil
+	}
+	sum := sha256.Sum256([]byte(param.AppKey + param.Timestamp + MasterSecret))
+	if param.Sign != fmt.Sprintf("%x", sum) {
+		return CodeParamError, "sign error", nil
+	}
+	token := newId("token-")
+	s.tokens[token] = true
+	expire := time.Now().Add(24*time.Hour).UnixNano() / 1e6
+	return CodeSuccess, "", map[string]string{
+		"expire_time": strconv.FormatInt(expire, 10),
+		"token":       token,
+	}
+}
+
+/*
+===============================================================
+推送
+===============================================================
+*/
+
+// pushSingle 按cid或别名单推
+func (s *Server) pushSingle(r *request) (int, string, interface{}) {
+	param := new(models.PushParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if param.Audience == nil {
+		return CodeParamError, "audience is empty", nil
+	}
+	var cids []string
+	if strings.HasSuffix(r.endpoint, "/cid") {
+		if len(param.Audience.Cid) != 1 {
+			return CodeParamError, "cid must be one", nil
+		}
+		cids = param.Audience.Cid
+	} else {
+		if len(param.Audience.Alias) != 1 {
+			return CodeParamError, "alias must be one", nil
+		}
+		cids = s.cidsByAlias(param.Audience.Alias[0])
+		if len(cids) == 0 {
+			return CodeNotFound, "alias not bound", nil
+		}
+	}
+	push := s.addPush(r, param, nil, cids)
+	return CodeSuccess, "", map[string]map[string]string{
+		push.TaskId: s.cidStatus(cids),
+	}
+}
+
+// pushApp 群推，包括 /push/all /push/tag /push/fast_custom_tag
+//
+//	设置了 schedule_time 时创建定时任务
+func (s *Server) pushApp(r *request) (int, string, interface{}) {
+	param := new(models.PushParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if param.Audience == nil {
+		return CodeParamError, "audience is empty", nil
+	}
+	var cids []string
+	switch r.endpoint {
+	case "/push/all":
+		if !param.Audience.IsAll() {
+			return CodeParamError, "audience must be all", nil
+		}
+		cids = s.allCids()
+	case "/push/tag":
+		if len(param.Audience.Tag) == 0 {
+			return CodeParamError, "tag is empty", nil
+		}
+		cids = s.matchTags(param.Audience.Tag)
+	default:
+		if param.Audience.FastCustomTag == "" {
+			return CodeParamError, "fast_custom_tag is empty", nil
+		}
+		cids = s.matchTags([]*models.Tag{models.TagCustom(param.Audience.FastCustomTag)})
+	}
+	push := s.addPush(r, param, nil, cids)
+	if param.Setting != nil && param.Setting.ScheduleTime > 0 {
+		s.schedules[push.TaskId] = &models.ScheduleTask{
+			CreateTime:          strconv.FormatInt(push.Time.UnixNano()/1e6, 10),
+			Status:              "pending",
+			TransmissionContent: getTransmission(param),
+			PushTime:            strconv.Itoa(param.Setting.ScheduleTime),
+		}
+	}
+	return CodeSuccess, "", map[string]string{"taskid": push.TaskId}
+}
+
+// createMessage 创建群推消息
+func (s *Server) createMessage(r *request) (int, string, interface{}) {
+	param := new(models.PushParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	taskId := newId("RASL-")
+	s.messages[taskId] = param
+	return CodeSuccess, "", map[string]string{"taskid": taskId}
+}
+
+// pushList 按cid群推
+func (s *Server) pushList(r *request) (int, string, interface{}) {
+	param := new(models.PushListParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	message, ok := s.messages[param.TaskId]
+	if !ok {
+		return CodeNotFound, "taskid not found", nil
+	}
+	if len(param.Audience.Cid) == 0 || len(param.Audience.Cid) > 1000 {
+		return CodeParamError, "cid size must be 1-1000", nil
+	}
+	push := s.addPush(r, message, param, param.Audience.Cid)
+	push.TaskId = param.TaskId
+	return CodeSuccess, "", map[string]map[string]string{
+		param.TaskId: s.cidStatus(param.Audience.Cid),
+	}
+}
+
+// addPush 记录一次推送
+func (s *Server) addPush(r *request, param *models.PushParam, listParam *models.PushListParam, cids []string) *Push {
+	push := &Push{
+		Method:    r.method,
+		Endpoint:  r.endpoint,
+		TaskId:    newId("RASA-"),
+		Param:     param,
+		ListParam: listParam,
+		Cids:      append([]string(nil), cids...),
+		Time:      time.Now(),
+	}
+	s.pushes = append(s.pushes, push)
+	return push
+}
+
+// cidStatus 返回cid的下发状态
+func (s *Server) cidStatus(cids []string) map[string]string {
+	status := make(map[string]string, len(cids))
+	for _, cid := range cids {
+		if d, ok := s.devices[cid]; ok && d.Online {
+			status[cid] = "successed_online"
+		} else {
+			status[cid] = "successed_offline"
+		}
+	}
+	return status
+}
+
+// getTransmission 返回透传内容
+func getTransmission(param *models.PushParam) string {
+	if param.PushMessage == nil {
+		return ""
+	}
+	return param.PushMessage.Transmission
+}
+
+/*
+===============================================================
+任务
+===============================================================
+*/
+
+// stopTask 停止任务
+func (s *Server) stopTask(r *request) (int, string, interface{}) {
+	taskId := r.params[0]
+	if s.findPushes(taskId) == nil {
+		return CodeNotFound, "taskid not found", nil
+	}
+	s.stopped[taskId] = true
+	return CodeSuccess, "", nil
+}
+
+// getSchedule 查询定时任务
+func (s *Server) getSchedule(r *request) (int, string, interface{}) {
+	taskId := r.params[0]
+	task, ok := s.schedules[taskId]
+	if !ok {
+		return CodeNotFound, "taskid not found", nil
+	}
+	return CodeSuccess, "", map[string]*models.ScheduleTask{taskId: task}
+}
+
+// deleteSchedule 删除定时任务
+func (s *Server) deleteSchedule(r *request) (int, string, interface{}) {
+	taskId := r.params[0]
+	task, ok := s.schedules[taskId]
+	if !ok {
+		return CodeNotFound, "taskid not found", nil
+	}
+	if task.Status != "pending" {
+		return CodeParamError, "task already sent", nil
+	}
+	delete(s.schedules, taskId)
+	return CodeSuccess, "", nil
+}
+
+// taskDetail 查询某任务下某cid的推送详情
+func (s *Server) taskDetail(r *request) (int, string, interface{}) {
+	cid, taskId := r.params[0], r.params[1]
+	for _, push := range s.findPushes(taskId) {
+		for _, c := range push.Cids {
+			if c == cid {
+				return CodeSuccess, "", map[string]interface{}{
+					"deatil": []map[string]string{
+						{"time": push.Time.Format("2006-01-02 15:04:05"), "event": "消息请求成功"},
+					},
+				}
+			}
+		}
+	}
+	return CodeNotFound, "cid not found in task", nil
+}
+
+// findPushes 返回任务的所有推送
+func (s *Server) findPushes(taskId string) []*Push {
+	var pushes []*Push
+	for _, push := range s.pushes {
+		if push.TaskId == taskId {
+			pushes = append(pushes, push)
+		}
+	}
+	return pushes
+}
+
+/*
+===============================================================
+用户
+===============================================================
+*/
+
+// bindAlias 绑定别名
+func (s *Server) bindAlias(r *request) (int, string, interface{}) {
+	param := new(models.AliasParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if len(param.DataList) == 0 || len(param.DataList) > 1000 {
+		return CodeParamError, "data_list size must be 1-1000", nil
+	}
+	for _, item := range param.DataList {
+		if item == nil || item.Cid == "" || item.Alias == "" {
+			return CodeParamError, "cid or alias is empty", nil
+		}
+	}
+	for _, item := range param.DataList {
+		s.aliases[item.Cid] = item.Alias
+	}
+	return CodeSuccess, "", nil
+}
+
+// unBindAlias 解绑别名
+func (s *Server) unBindAlias(r *request) (int, string, interface{}) {
+	param := new(models.AliasParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	for _, item := range param.DataList {
+		if item != nil && s.aliases[item.Cid] == item.Alias {
+			delete(s.aliases, item.Cid)
+		}
+	}
+	return CodeSuccess, "", nil
+}
+
+// unBindAllAlias 解绑别名的所有cid
+func (s *Server) unBindAllAlias(r *request) (int, string, interface{}) {
+	for _, cid := range s.cidsByAlias(r.params[0]) {
+		delete(s.aliases, cid)
+	}
+	return CodeSuccess, "", nil
+}
+
+// aliasByCid 按cid查询别名
+func (s *Server) aliasByCid(r *request) (int, string, interface{}) {
+	alias, ok := s.aliases[r.params[0]]
+	if !ok {
+		return CodeNotFound, "alias not found", nil
+	}
+	return CodeSuccess, "", map[string]string{"alias": alias}
+}
+
+// cidByAlias 按别名查询cid
+func (s *Server) cidByAlias(r *request) (int, string, interface{}) {
+	cids := s.cidsByAlias(r.params[0])
+	if len(cids) == 0 {
+		return CodeNotFound, "cid not found", nil
+	}
+	return CodeSuccess, "", map[string][]string{"cid": cids}
+}
+
+// bindTags 一个cid绑定多个标签
+func (s *Server) bindTags(r *request) (int, string, interface{}) {
+	param := new(models.CustomTagsParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if len(param.CustomTag) > 100 {
+		return CodeParamError, "custom_tag size must be <= 100", nil
+	}
+	s.tags[r.params[0]] = append([]string(nil), param.CustomTag...)
+	return CodeSuccess, "", nil
+}
+
+// searchTags 查询cid的标签
+func (s *Server) searchTags(r *request) (int, string, interface{}) {
+	cid := r.params[0]
+	return CodeSuccess, "", map[string][]string{cid: s.tags[cid]}
+}
+
+// bindTagBatch 一批cid绑定一个标签
+func (s *Server) bindTagBatch(r *request) (int, string, interface{}) {
+	param := new(models.TagCidParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if len(param.Cid) == 0 || len(param.Cid) > 1000 {
+		return CodeParamError, "cid size must be 1-1000", nil
+	}
+	tag := r.params[0]
+	result := make(map[string]string, len(param.Cid))
+	for _, cid := range param.Cid {
+		if !hasTag(s.tags[cid], tag) {
+			s.tags[cid] = append(s.tags[cid], tag)
+		}
+		result[cid] = "true"
+	}
+	return CodeSuccess, "", result
+}
+
+// unBindTagBatch 一批cid解绑一个标签
+func (s *Server) unBindTagBatch(r *request) (int, string, interface{}) {
+	param := new(models.TagCidParam)
+	if err := json.Unmarshal(r.body, param); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	if len(param.Cid) == 0 || len(param.Cid) > 1000 {
+		return CodeParamError, "cid size must be 1-1000", nil
+	}
+	tag := r.params[0]
+	result := make(map[string]string, len(param.Cid))
+	for _, cid := range param.Cid {
+		tags := s.tags[cid][:0]
+		for _, t := range s.tags[cid] {
+			if t != tag {
+				tags = append(tags, t)
+			}
+		}
+		s.tags[cid] = tags
+		result[cid] = "true"
+	}
+	return CodeSuccess, "", result
+}
+
+// hasTag 标签是否已存在
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// userStatus 查询用户状态
+func (s *Server) userStatus(r *request) (int, string, interface{}) {
+	cid := r.params[0]
+	d, ok := s.devices[cid]
+	if !ok {
+		return CodeNotFound, "cid not found", nil
+	}
+	status := "offline"
+	if d.Online {
+		status = "online"
+	}
+	return CodeSuccess, "", map[string]map[string]string{
+		cid: {
+			"last_login_time": strconv.FormatInt(d.CreatedAt.UnixNano()/1e6, 10),
+			"status":          status,
+		},
+	}
+}
+
+// userDetail 查询用户信息
+func (s *Server) userDetail(r *request) (int, string, interface{}) {
+	cid := r.params[0]
+	valid := make(map[string]map[string]string)
+	var invalid []string
+	if d, ok := s.devices[cid]; ok {
+		valid[cid] = map[string]string{
+			"client_app_id": AppId,
+			"phone_type":    d.PhoneType,
+			"create_time":   d.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+	} else {
+		invalid = append(invalid, cid)
+	}
+	return CodeSuccess, "", map[string]interface{}{
+		"validCids":   valid,
+		"invalidCids": invalid,
+	}
+}
+
+// userCount 按标签统计用户数
+func (s *Server) userCount(r *request) (int, string, interface{}) {
+	audience := new(models.Audience)
+	if err := json.Unmarshal(r.body, audience); err != nil {
+		return CodeParamError, err.Error(), nil
+	}
+	return CodeSuccess, "", map[string]int{"user_count": len(s.matchTags(audience.Tag))}
+}
+
+/*
+===============================================================
+统计
+===============================================================
+*/
+
+// reportTask 查询任务的推送数据，支持多个taskId
+func (s *Server) reportTask(r *request) (int, string, interface{}) {
+	data := make(map[string]*models.PushReport)
+	for _, taskId := range strings.Split(r.params[0], ",") {
+		pushes := s.findPushes(taskId)
+		if len(pushes) == 0 {
+			continue
+		}
+		data[taskId] = s.report(pushes)
+	}
+	return CodeSuccess, "", data
+}
+
+// reportGroup 查询任务组的推送数据
+func (s *Server) reportGroup(r *request) (int, string, interface{}) {
+	groupName := r.params[0]
+	var pushes []*Push
+	for _, push := range s.pushes {
+		if push.Param != nil && push.Param.GroupName == groupName {
+			pushes = append(pushes, push)
+		}
+	}
+	return CodeSuccess, "", map[string]*models.PushReport{groupName: s.report(pushes)}
+}
+
+// reportPushDate 查询某天的推送数据
+func (s *Server) reportPushDate(r *request) (int, string, interface{}) {
+	date := r.params[0]
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return CodeParamError, "date format error", nil
+	}
+	var pushes []*Push
+	for _, push := range s.pushes {
+		if push.Time.Format("2006-01-02") == date {
+			pushes = append(pushes, push)
+		}
+	}
+	return CodeSuccess, "", map[string]*models.PushReport{date: s.report(pushes)}
+}
+
+// reportUserDate 查询某天的用户数据
+func (s *Server) reportUserDate(r *request) (int, string, interface{}) {
+	date := r.params[0]
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return CodeParamError, "date format error", nil
+	}
+	report := &models.UserReport{}
+	end := day.AddDate(0, 0, 1)
+	for _, d := range s.devices {
+		if !d.CreatedAt.Before(end) {
+			continue
+		}
+		report.AccumulativeNum++
+		if !d.CreatedAt.Before(day) {
+			report.RegisterNum++
+		}
+		if d.Online {
+			report.ActiveNum++
+			report.OnlineNum++
+		}
+	}
+	return CodeSuccess, "", map[string]*models.UserReport{date: report}
+}
+
+// reportOnlineUser 查询在线用户数
+//
+//	只返回当前整点的在线用户数
+func (s *Server) reportOnlineUser(r *request) (int, string, interface{}) {
+	var online int64
+	for _, d := range s.devices {
+		if d.Online {
+			online++
+		}
+	}
+	hour := time.Now().Truncate(time.Hour).UnixNano() / 1e6
+	return CodeSuccess, "", &models.OnlineUserReport{
+		OnlineStatics: map[string]int64{strconv.FormatInt(hour, 10): online},
+	}
+}
+
+// report 汇总推送数据
+//
+//	下发数为目标cid数，在线设备计为接收和展示，已停止的任务不计接收数
+func (s *Server) report(pushes []*Push) *models.PushReport {
+	total := &models.ReportStat{}
+	for _, push := range pushes {
+		stat := &models.ReportStat{
+			MsgNum:    int64(len(push.Cids)),
+			TargetNum: int64(len(push.Cids)),
+		}
+		if !s.stopped[push.TaskId] {
+			for _, cid := range push.Cids {
+				if d, ok := s.devices[cid]; ok && d.Online {
+					stat.ReceiveNum++
+					stat.DisplayNum++
+				}
+			}
+		}
+		total.Add(stat)
+	}
+	return &models.PushReport{
+		Total:        total,
+		Detail:       map[string]*models.ReportStat{"gt": total},
+		ActionCntMap: map[string]int64{},
+	}
+}
@@ -0,0 +1,344 @@
+/*
+个推测试服务
+
+在进程内用 httptest.Server 模拟个推的 /auth /push /user /task /report 接口，
+数据保存在内存中，可用于业务代码的单元测试，不需要访问个推和redis
+
+	srv := getuitest.NewServer()
+	defer srv.Close()
+	client, _ := srv.NewClient(nil)
+	client.PushSingleByCid(...)
+	pushes := srv.Pushes()
+
+不需要http请求时，可以使用 NewFake 返回的内存实现代替 getuipush.PushService
+*/
+
+package getuitest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/models"
+)
+
+const (
+	// AppId 测试服务使用的appId
+	AppId = "getuitest-app-id"
+
+	// AppKey 测试服务使用的appKey
+	AppKey = "getuitest-app-key"
+
+	// AppSecret 测试服务使用的appSecret
+	AppSecret = "getuitest-app-secret"
+
+	// MasterSecret 测试服务使用的masterSecret
+	MasterSecret = "getuitest-master-secret"
+)
+
+// 测试服务返回的错误代码
+const (
+	CodeSuccess      = 0     //成功
+	CodeTokenInvalid = 10001 //token错误或已失效
+	CodeParamError   = 20001 //参数错误
+	CodeNotFound     = 20002 //任务、cid或别名不存在
+)
+
+// Device 测试服务中的设备
+type Device struct {
+	Cid       string    //cid
+	PhoneType string    //android ios harmony
+	Region    string    //地区编码
+	Online    bool      //是否在线
+	CreatedAt time.Time //注册时间
+}
+
+// Push 测试服务收到的一次推送
+type Push struct {
+	Method    string                //请求方法
+	Endpoint  string                //接口模板，如 /push/all
+	TaskId    string                //任务id
+	Param     *models.PushParam     //推送参数，按cid群推时为 /push/list/message 创建的消息
+	ListParam *models.PushListParam //按cid群推时的参数
+	Cids      []string              //推送的目标cid
+	Time      time.Time             //收到推送的时间
+}
+
+// injectedError 注入的错误
+type injectedError struct {
+	code int
+	msg  string
+}
+
+// Server 个推测试服务
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	tokens    map[string]bool
+	devices   map[string]*Device
+	aliases   map[string]string   //cid -> alias
+	tags      map[string][]string //cid -> 自定义标签
+	pushes    []*Push
+	messages  map[string]*models.PushParam //taskId -> /push/list/message 创建的消息
+	schedules map[string]*models.ScheduleTask
+	stopped   map[string]bool
+	errors    map[string]*injectedError
+	latency   time.Duration
+}
+
+// NewServer 启动测试服务
+//
+//	使用完毕后需调用 Close
+func NewServer() *Server {
+	s := &Server{
+		tokens:    make(map[string]bool),
+		devices:   make(map[string]*Device),
+		aliases:   make(map[string]string),
+		tags:      make(map[string][]string),
+		messages:  make(map[string]*models.PushParam),
+		schedules: make(map[string]*models.ScheduleTask),
+		stopped:   make(map[string]bool),
+		errors:    make(map[string]*injectedError),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Config 返回测试服务的个推配置
+func (s *Server) Config() *getuipush.PushConfig {
+	return &getuipush.PushConfig{
+		AppId:        AppId,
+		AppKey:       AppKey,
+		AppSecret:    AppSecret,
+		MasterSecret: MasterSecret,
+	}
+}
+
+// BaseURL 返回测试服务的API地址，可设置到 AppConfig.BaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + "/v2/"
+}
+
+// NewClient 返回请求测试服务的 PushClient
+//
+//	app 会被复制，并设置 BaseURL 和内存中的 TokenStore，可以为nil
+func (s *Server) NewClient(app *getuipush.AppConfig) (*getuipush.PushClient, error) {
+	conf := &getuipush.AppConfig{}
+	if app != nil {
+		*conf = *app
+	}
+	conf.BaseURL = s.BaseURL()
+	conf.TokenStore = getuipush.NewMemoryTokenStore()
+	return getuipush.NewPushClient(s.Config(), nil, conf, false)
+}
+
+// AddDevice 添加设备，用于按标签推送和用户数统计
+func (s *Server) AddDevice(cid, phoneType, region string) *Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := &Device{
+		Cid:       cid,
+		PhoneType: phoneType,
+		Region:    region,
+		Online:    true,
+		CreatedAt: time.Now(),
+	}
+	s.devices[cid] = d
+	return d
+}
+
+// Alias 返回cid绑定的别名
+func (s *Server) Alias(cid string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aliases[cid]
+}
+
+// CidsByAlias 返回别名绑定的cid
+func (s *Server) CidsByAlias(alias string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cidsByAlias(alias)
+}
+
+// Tags 返回cid绑定的自定义标签
+func (s *Server) Tags(cid string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.tags[cid]...)
+}
+
+// Pushes 返回收到的所有推送
+func (s *Server) Pushes() []*Push {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Push(nil), s.pushes...)
+}
+
+// LastPush 返回最后一次推送，没有时返回nil
+func (s *Server) LastPush() *Push {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pushes) == 0 {
+		return nil
+	}
+	return s.pushes[len(s.pushes)-1]
+}
+
+// ScheduleTask 返回定时任务，不存在或已取消时返回nil
+func (s *Server) ScheduleTask(taskId string) *models.ScheduleTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.schedules[taskId]
+}
+
+// Reset 清空所有数据、注入的错误和延迟
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+	s.devices = make(map[string]*Device)
+	s.aliases = make(map[string]string)
+	s.tags = make(map[string][]string)
+	s.pushes = nil
+	s.messages = make(map[string]*models.PushParam)
+	s.schedules = make(map[string]*models.ScheduleTask)
+	s.stopped = make(map[string]bool)
+	s.errors = make(map[string]*injectedError)
+	s.latency = 0
+}
+
+// SetError 让接口返回指定的错误代码
+//
+//	endpoint 为接口模板，如 "/push/all"、"POST /user/alias"、"/user/status/:cid"，"*" 表示所有接口
+//	code 为0时取消该接口的错误
+func (s *Server) SetError(endpoint string, code int, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code == CodeSuccess {
+		delete(s.errors, endpoint)
+		return
+	}
+	s.errors[endpoint] = &injectedError{code: code, msg: msg}
+}
+
+// ClearErrors 取消所有注入的错误
+func (s *Server) ClearErrors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = make(map[string]*injectedError)
+}
+
+// SetLatency 设置每个请求的延迟
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// ExpireTokens 让已发放的token全部失效，用于测试token过期
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+}
+
+// getError 返回接口注入的错误
+func (s *Server) getError(method, endpoint string) *injectedError {
+	if e, ok := s.errors[method+" "+endpoint]; ok {
+		return e
+	}
+	if e, ok := s.errors[endpoint]; ok {
+		return e
+	}
+	return s.errors["*"]
+}
+
+// cidsByAlias 返回别名绑定的cid，需持有锁
+func (s *Server) cidsByAlias(alias string) []string {
+	var cids []string
+	for cid, a := range s.aliases {
+		if a == alias {
+			cids = append(cids, cid)
+		}
+	}
+	sort.Strings(cids)
+	return cids
+}
+
+// matchTags 返回满足所有标签条件的cid，需持有锁
+func (s *Server) matchTags(tags []*models.Tag) []string {
+	var cids []string
+	for cid, d := range s.devices {
+		if s.matchDevice(d, tags) {
+			cids = append(cids, cid)
+		}
+	}
+	sort.Strings(cids)
+	return cids
+}
+
+// matchDevice 设备是否满足所有标签条件
+func (s *Server) matchDevice(d *Device, tags []*models.Tag) bool {
+	for _, tag := range tags {
+		var has []string
+		switch tag.Key {
+		case models.TagKeyPhoneType:
+			has = []string{d.PhoneType}
+		case models.TagKeyRegion:
+			has = []string{d.Region}
+		case models.TagKeyCustomTag:
+			has = s.tags[d.Cid]
+		default:
+			return false
+		}
+		if !matchValues(tag.OptType, has, tag.Values) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchValues 按 opt_type 比较设备的值和标签的值
+func matchValues(opt string, has, values []string) bool {
+	count := 0
+	for _, v := range values {
+		for _, h := range has {
+			if h == v {
+				count++
+				break
+			}
+		}
+	}
+	switch opt {
+	case models.TagOptAnd:
+		return count == len(values)
+	case models.TagOptNot:
+		return count == 0
+	default:
+		return count > 0
+	}
+}
+
+// allCids 返回所有设备的cid，需持有锁
+func (s *Server) allCids() []string {
+	cids := make([]string, 0, len(s.devices))
+	for cid := range s.devices {
+		cids = append(cids, cid)
+	}
+	sort.Strings(cids)
+	return cids
+}
+
+// newId 生成token或taskId
+func newId(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
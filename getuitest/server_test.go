@@ -0,0 +1,640 @@
+package getuitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/models"
+)
+
+var testPayload = &models.CustomMessage{Title: "标题", Content: "内容", Url: "/home"}
+
+func newTestClient(t *testing.T, s *Server) *getuipush.PushClient {
+	t.Helper()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	return client
+}
+
+func TestServerAuth(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newTestClient(t, s)
+
+	token, err := client.GetToken()
+	if err != nil || token == "" {
+		t.Fatalf("GetToken = %q, %v", token, err)
+	}
+	again, err := client.GetToken()
+	if err != nil || again != token {
+		t.Fatalf("GetToken should reuse the stored token, got %q, %v", again, err)
+	}
+
+	tests := []struct {
+		name string
+		conf *getuipush.PushConfig
+	}{
+		{name: "wrong master secret", conf: &getuipush.PushConfig{AppId: AppId, AppKey: AppKey, AppSecret: AppSecret, MasterSecret: "wrong"}},
+		{name: "wrong app key", conf: &getuipush.PushConfig{AppId: AppId, AppKey: "wrong", AppSecret: AppSecret, MasterSecret: MasterSecret}},
+		{name: "wrong app id", conf: &getuipush.PushConfig{AppId: "wrong", AppKey: AppKey, AppSecret: AppSecret, MasterSecret: MasterSecret}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := getuipush.NewPushClient(tt.conf, nil, &getuipush.AppConfig{
+				BaseURL:    s.BaseURL(),
+				TokenStore: getuipush.NewMemoryTokenStore(),
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token, err := c.GetToken(); err == nil {
+				t.Fatalf("GetToken = %q, want error", token)
+			}
+		})
+	}
+}
+
+func TestServerExpireTokens(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	client := newTestClient(t, s)
+	if _, err := client.SearchStatus("cid1"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.ExpireTokens()
+	_, err := client.SearchStatus("cid1")
+	if err == nil || !strings.Contains(err.Error(), "10001") {
+		t.Fatalf("SearchStatus after ExpireTokens error = %v, want code %d", err, CodeTokenInvalid)
+	}
+
+	// 新的client没有缓存token，会重新鉴权
+	if _, err = newTestClient(t, s).SearchStatus("cid1"); err != nil {
+		t.Fatalf("SearchStatus with a new token error: %v", err)
+	}
+}
+
+func TestServerAuthSign(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	tests := []struct {
+		name  string
+		param *models.TokenParam
+		code  int
+		msg   string
+	}{
+		{name: "valid", param: signedParam(AppKey, MasterSecret), code: CodeSuccess, msg: "success"},
+		{name: "wrong master secret", param: signedParam(AppKey, "wrong"), code: CodeParamError, msg: "sign error"},
+		{name: "wrong app key", param: signedParam("wrong", MasterSecret), code: CodeParamError, msg: "appkey error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := json.Marshal(tt.param)
+			resp, err := http.Post(s.BaseURL()+AppId+"/auth", "application/json", strings.NewReader(string(b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var body struct {
+				Code int    `json:"code"`
+				Msg  string `json:"msg"`
+			}
+			if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.code || body.Msg != tt.msg {
+				t.Fatalf("auth = %d %q, want %d %q", body.Code, body.Msg, tt.code, tt.msg)
+			}
+		})
+	}
+}
+
+func signedParam(appKey, masterSecret string) *models.TokenParam {
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	sum := sha256.Sum256([]byte(appKey + timestamp + masterSecret))
+	return &models.TokenParam{Sign: hex.EncodeToString(sum[:]), Timestamp: timestamp, AppKey: appKey}
+}
+
+func TestServerRejectsMissingToken(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	resp, err := http.Get(s.BaseURL() + AppId + "/user/status/cid1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Code int `json:"code"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != CodeTokenInvalid {
+		t.Fatalf("code = %d, want %d", body.Code, CodeTokenInvalid)
+	}
+}
+
+func TestServerPush(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	s.AddDevice("cid2", "ios", "12000000")
+	client := newTestClient(t, s)
+	if _, err := client.BindAlias(&models.Alias{Cid: "cid2", Alias: "user2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BindTags("cid1", &models.CustomTagsParam{CustomTag: []string{"vip"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		push     func() error
+		endpoint string
+		cids     []string
+	}{
+		{
+			name: "single cid",
+			push: func() error {
+				_, err := client.PushSingleByCid(int(getuipush.InstantMsg), "cid1", testPayload)
+				return err
+			},
+			endpoint: "/push/single/cid",
+			cids:     []string{"cid1"},
+		},
+		{
+			name: "single alias",
+			push: func() error {
+				_, err := client.PushSingleByAlias(int(getuipush.InstantMsg), "user2", testPayload)
+				return err
+			},
+			endpoint: "/push/single/alias",
+			cids:     []string{"cid2"},
+		},
+		{
+			name: "all",
+			push: func() error {
+				_, err := client.PushAll(int(getuipush.ArticleMsg), 0, testPayload)
+				return err
+			},
+			endpoint: "/push/all",
+			cids:     []string{"cid1", "cid2"},
+		},
+		{
+			name: "client type",
+			push: func() error {
+				_, err := client.PushAllByClient(int(getuipush.ArticleMsg), 0, getuipush.IOS, testPayload)
+				return err
+			},
+			endpoint: "/push/tag",
+			cids:     []string{"cid2"},
+		},
+		{
+			name: "custom tag",
+			push: func() error {
+				_, err := client.PushAllByCustomTag(int(getuipush.ArticleMsg), 0, []string{"vip"}, testPayload)
+				return err
+			},
+			endpoint: "/push/tag",
+			cids:     []string{"cid1"},
+		},
+		{
+			name: "fast custom tag",
+			push: func() error {
+				_, err := client.PushAppByFastCustomTag(int(getuipush.ArticleMsg), 0, "vip", testPayload)
+				return err
+			},
+			endpoint: "/push/fast_custom_tag",
+			cids:     []string{"cid1"},
+		},
+		{
+			name: "list",
+			push: func() error {
+				_, err := client.PushListByCid(int(getuipush.ArticleMsg), []string{"cid1", "cid2"}, testPayload)
+				return err
+			},
+			endpoint: "/push/list/cid",
+			cids:     []string{"cid1", "cid2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.push(); err != nil {
+				t.Fatal(err)
+			}
+			push := s.LastPush()
+			if push.Endpoint != tt.endpoint {
+				t.Errorf("endpoint = %s, want %s", push.Endpoint, tt.endpoint)
+			}
+			if !reflect.DeepEqual(push.Cids, tt.cids) {
+				t.Errorf("cids = %v, want %v", push.Cids, tt.cids)
+			}
+			if push.Param == nil || push.Param.PushMessage == nil {
+				t.Fatal("push param is empty")
+			}
+		})
+	}
+	if n := len(s.Pushes()); n != len(tests) {
+		t.Fatalf("Pushes() has %d items, want %d", n, len(tests))
+	}
+}
+
+func TestServerMatchValues(t *testing.T) {
+	tests := []struct {
+		opt    string
+		has    []string
+		values []string
+		want   bool
+	}{
+		{models.TagOptOr, []string{"a"}, []string{"a", "b"}, true},
+		{models.TagOptOr, []string{"c"}, []string{"a", "b"}, false},
+		{models.TagOptOr, nil, []string{"a"}, false},
+		{"", []string{"b"}, []string{"a", "b"}, true},
+		{models.TagOptAnd, []string{"a", "b", "c"}, []string{"a", "b"}, true},
+		{models.TagOptAnd, []string{"a"}, []string{"a", "b"}, false},
+		{models.TagOptNot, []string{"c"}, []string{"a", "b"}, true},
+		{models.TagOptNot, nil, []string{"a"}, true},
+		{models.TagOptNot, []string{"a", "c"}, []string{"a", "b"}, false},
+	}
+	for _, tt := range tests {
+		if got := matchValues(tt.opt, tt.has, tt.values); got != tt.want {
+			t.Errorf("matchValues(%q, %v, %v) = %v, want %v", tt.opt, tt.has, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestServerMatchDevice(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("a1", "android", "11000000")
+	s.AddDevice("a2", "android", "12000000")
+	s.AddDevice("i1", "ios", "11000000")
+	s.AddDevice("h1", "harmony", "13000000")
+	client := newTestClient(t, s)
+	if _, err := client.BindTagToCids("vip", []string{"a1", "i1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BindTagToCids("gk2025", []string{"a1", "a2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tags []*models.Tag
+		want []string
+	}{
+		{name: "phone type", tags: []*models.Tag{models.TagPhoneType("android")}, want: []string{"a1", "a2"}},
+		{name: "phone types or", tags: []*models.Tag{models.TagPhoneType("ios", "harmony")}, want: []string{"h1", "i1"}},
+		{name: "region not", tags: []*models.Tag{models.TagRegion("11000000").Not()}, want: []string{"a2", "h1"}},
+		{name: "custom and", tags: []*models.Tag{models.TagCustom("vip", "gk2025").And()}, want: []string{"a1"}},
+		{name: "intersection", tags: []*models.Tag{models.TagCustom("vip"), models.TagPhoneType("ios")}, want: []string{"i1"}},
+		{name: "portrait is unsupported", tags: []*models.Tag{models.TagPortrait("x")}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := client.PreviewAudience(tt.tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != int64(len(tt.want)) {
+				t.Errorf("PreviewAudience = %d, want %d", count, len(tt.want))
+			}
+			if len(tt.want) == 0 {
+				return
+			}
+			if _, err = client.PushAllByLogicTags(int(getuipush.ArticleMsg), 0, tt.tags, testPayload); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.LastPush().Cids; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerSetError(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "")
+	client := newTestClient(t, s)
+	// "*" 也会匹配 /auth，先获取token
+	if _, err := client.GetToken(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SetError("*", 30001, "any")
+	s.SetError("/user/status/:cid", 30002, "endpoint")
+	s.SetError("GET /user/status/:cid", 30003, "method")
+
+	steps := []struct {
+		name  string
+		clear string
+		want  string
+	}{
+		{name: "method and endpoint first", want: "30003"},
+		{name: "then endpoint", clear: "GET /user/status/:cid", want: "30002"},
+		{name: "then any", clear: "/user/status/:cid", want: "30001"},
+		{name: "none", clear: "*", want: ""},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.clear != "" {
+				s.SetError(step.clear, CodeSuccess, "")
+			}
+			_, err := client.SearchStatus("cid1")
+			if step.want == "" {
+				if err != nil {
+					t.Fatalf("SearchStatus error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), step.want) {
+				t.Fatalf("SearchStatus error = %v, want code %s", err, step.want)
+			}
+		})
+	}
+
+	// 其他接口不受影响
+	s.SetError("POST /push/all", 30004, "push")
+	if _, err := client.SearchStatus("cid1"); err != nil {
+		t.Fatalf("SearchStatus error: %v", err)
+	}
+	if _, err := client.PushAll(int(getuipush.ArticleMsg), 0, testPayload); err == nil || !strings.Contains(err.Error(), "30004") {
+		t.Fatalf("PushAll error = %v, want code 30004", err)
+	}
+	s.ClearErrors()
+	if _, err := client.PushAll(int(getuipush.ArticleMsg), 0, testPayload); err != nil {
+		t.Fatalf("PushAll after ClearErrors error: %v", err)
+	}
+
+	// 鉴权接口也可以注入错误
+	s.SetError("/auth", 30005, "auth")
+	if _, err := newTestClient(t, s).GetToken(); err == nil {
+		t.Fatal("GetToken should fail")
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "")
+	client := newTestClient(t, s)
+	if _, err := client.GetToken(); err != nil {
+		t.Fatal(err)
+	}
+	s.SetLatency(50 * time.Millisecond)
+	start := time.Now()
+	if _, err := client.SearchStatus("cid1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("request took %s, want at least 50ms", elapsed)
+	}
+}
+
+func TestServerScheduleTask(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "")
+	client := newTestClient(t, s)
+
+	at := time.Now().Add(time.Hour)
+	resp, err := client.PushAllAt(int(getuipush.ArticleMsg), at, testPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TaskId == "" {
+		t.Fatal("TaskId is empty")
+	}
+	task, err := client.GetScheduleTask(resp.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "pending" {
+		t.Errorf("status = %s, want pending", task.Status)
+	}
+	if s.ScheduleTask(resp.TaskId) == nil {
+		t.Fatal("ScheduleTask should exist")
+	}
+
+	if _, err = client.CancelScheduleTask(resp.TaskId); err != nil {
+		t.Fatal(err)
+	}
+	if s.ScheduleTask(resp.TaskId) != nil {
+		t.Fatal("ScheduleTask should be removed after cancel")
+	}
+	if _, err = client.CancelScheduleTask(resp.TaskId); err == nil || !strings.Contains(err.Error(), "20002") {
+		t.Fatalf("second cancel error = %v, want code %d", err, CodeNotFound)
+	}
+	if _, err = client.GetScheduleTask(resp.TaskId); err == nil {
+		t.Fatal("GetScheduleTask after cancel should fail")
+	}
+}
+
+func TestServerTask(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "")
+	client := newTestClient(t, s)
+
+	list, err := client.PushListByCid(int(getuipush.ArticleMsg), []string{"cid1"}, testPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskId := s.LastPush().TaskId
+	if len(list) != 1 || taskId == "" {
+		t.Fatalf("PushListByCid = %v, taskId %q", list, taskId)
+	}
+
+	detail, err := client.SearchTaskDetailByCid("cid1", taskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detail.Data.Deatil) == 0 {
+		t.Fatal("task detail is empty")
+	}
+	detail, err = client.SearchTaskDetailByCid("cid2", taskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Code != CodeNotFound {
+		t.Fatalf("code for another cid = %d, want %d", detail.Code, CodeNotFound)
+	}
+
+	reports, err := client.ReportPushTasks(taskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reports[taskId].Total.ReceiveNum; got != 1 {
+		t.Errorf("receive_num = %d, want 1", got)
+	}
+	if _, err = client.StopTask(taskId); err != nil {
+		t.Fatal(err)
+	}
+	reports, err = client.ReportPushTasks(taskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reports[taskId].Total.ReceiveNum; got != 0 {
+		t.Errorf("receive_num after StopTask = %d, want 0", got)
+	}
+	if _, err = client.StopTask("unknown"); err == nil {
+		t.Fatal("StopTask for an unknown task should fail")
+	}
+}
+
+func TestServerUser(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "11000000")
+	s.AddDevice("cid2", "ios", "11000000")
+	client := newTestClient(t, s)
+
+	result, err := client.BindAliases([]*models.Alias{
+		{Cid: "cid1", Alias: "user1"},
+		{Cid: "cid2", Alias: "user1"},
+	})
+	if err != nil || len(result.Failed) != 0 {
+		t.Fatalf("BindAliases = %v, %v", result, err)
+	}
+	if got := s.CidsByAlias("user1"); !reflect.DeepEqual(got, []string{"cid1", "cid2"}) {
+		t.Fatalf("CidsByAlias = %v", got)
+	}
+	resp, err := client.SearchCidByAlias("user1")
+	if err != nil || !strings.Contains(resp.Data, "cid1") {
+		t.Fatalf("SearchCidByAlias = %v, %v", resp, err)
+	}
+	resp, err = client.SearchAliasByCid("cid1")
+	if err != nil || !strings.Contains(resp.Data, "user1") {
+		t.Fatalf("SearchAliasByCid = %v, %v", resp, err)
+	}
+	if _, err = client.UnBindAlias(&models.Alias{Cid: "cid1", Alias: "user1"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Alias("cid1") != "" || s.Alias("cid2") != "user1" {
+		t.Fatalf("UnBindAlias should only remove cid1")
+	}
+	if _, err = client.UnBindAllAlias("user1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.SearchCidByAlias("user1"); err == nil {
+		t.Fatal("SearchCidByAlias after UnBindAllAlias should fail")
+	}
+
+	if _, err = client.BindTags("cid1", &models.CustomTagsParam{CustomTag: []string{"vip", "gk2025"}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.SearchTags("cid1")
+	if err != nil || !strings.Contains(resp.Data, "gk2025") {
+		t.Fatalf("SearchTags = %v, %v", resp, err)
+	}
+	if _, err = client.UnBindTagFromCids("vip", []string{"cid1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Tags("cid1"); !reflect.DeepEqual(got, []string{"gk2025"}) {
+		t.Fatalf("Tags = %v", got)
+	}
+
+	resp, err = client.SearchStatus("cid1")
+	if err != nil || !strings.Contains(resp.Data, "online") {
+		t.Fatalf("SearchStatus = %v, %v", resp, err)
+	}
+	resp, err = client.SearchUser("cid2")
+	if err != nil || !strings.Contains(resp.Data, "ios") {
+		t.Fatalf("SearchUser = %v, %v", resp, err)
+	}
+	if _, err = client.SearchStatus("unknown"); err == nil {
+		t.Fatal("SearchStatus for an unknown cid should fail")
+	}
+	resp, err = client.GetUserCount([]*models.Tag{models.TagRegion("11000000")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count struct {
+		UserCount int `json:"user_count"`
+	}
+	if err = json.Unmarshal([]byte(resp.Data), &count); err != nil || count.UserCount != 2 {
+		t.Fatalf("GetUserCount = %s, %v", resp.Data, err)
+	}
+}
+
+func TestServerReport(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "")
+	s.AddDevice("cid2", "ios", "")
+	client := newTestClient(t, s)
+
+	if _, err := client.PushAll(int(getuipush.ArticleMsg), 0, testPayload, getuipush.WithGroupName("spring")); err != nil {
+		t.Fatal(err)
+	}
+	group, err := client.ReportPushGroup("spring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Total.TargetNum != 2 {
+		t.Errorf("group target_num = %d, want 2", group.Total.TargetNum)
+	}
+	empty, err := client.ReportPushGroup("autumn")
+	if err != nil || empty.Total.TargetNum != 0 {
+		t.Errorf("ReportPushGroup for an unknown group = %+v, %v", empty, err)
+	}
+
+	day, err := client.ReportPushDate(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.Total.TargetNum != 2 {
+		t.Errorf("date target_num = %d, want 2", day.Total.TargetNum)
+	}
+	users, err := client.ReportUserDates(time.Now().AddDate(0, 0, -1), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := users[time.Now().Format("2006-01-02")]
+	if len(users) != 2 || today == nil || today.RegisterNum != 2 || today.OnlineNum != 2 {
+		t.Errorf("ReportUserDates = %v", users)
+	}
+	online, err := client.ReportOnlineUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range online {
+		if n != 2 {
+			t.Errorf("online = %d, want 2", n)
+		}
+	}
+	if len(online) != 1 {
+		t.Errorf("ReportOnlineUser = %v", online)
+	}
+}
+
+func TestServerReset(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDevice("cid1", "android", "")
+	client := newTestClient(t, s)
+	if _, err := client.PushSingleByCid(int(getuipush.InstantMsg), "cid1", testPayload); err != nil {
+		t.Fatal(err)
+	}
+	s.SetError("*", 30001, "any")
+	s.Reset()
+	if len(s.Pushes()) != 0 || s.LastPush() != nil {
+		t.Fatal("Reset should clear pushes")
+	}
+	// token也被清空，需要新的client
+	if _, err := newTestClient(t, s).SearchUser("cid1"); err != nil {
+		t.Fatalf("SearchUser after Reset error: %v", err)
+	}
+}
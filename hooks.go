@@ -0,0 +1,103 @@
+package getuipush
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// RequestInfo 一次API请求的信息
+type RequestInfo struct {
+	AppId     string //个推appId
+	Method    string //请求方法
+	Path      string //请求路径，不含 APIURL，如 {appId}/user/status/{cid}
+	Endpoint  string //接口模板，如 /user/status/:cid，适合作为指标的label
+	RequestId string //推送时的request_id
+	TaskId    string //请求中的taskid
+}
+
+// RequestResult 一次API请求的结果
+type RequestResult struct {
+	Status  int           //http状态码，请求失败时为0
+	Code    int           //个推返回的code，无法解析时为-1
+	TaskId  string        //返回的taskid
+	Latency time.Duration //耗时
+	Err     error         //请求失败时的错误
+}
+
+// Tracer 链路追踪
+//
+//	每次请求API前调用 StartRequest，请求结束后调用其返回的方法
+//	ctx 为 WithContext 设置的context，未设置时为 context.Background()
+//	返回的context用于发送http请求，可适配OpenTelemetry：在 StartRequest 中用ctx创建span并返回包含span的context，在返回的方法中设置属性并结束span
+type Tracer interface {
+	StartRequest(ctx context.Context, info *RequestInfo) (context.Context, func(result *RequestResult))
+}
+
+// Metrics 监控指标
+//
+//	可适配Prometheus或OpenTelemetry，本包不依赖任何监控库
+//	ObserveRequest 可用于请求数、耗时分布、按个推code统计的错误数
+//	ObserveTokenRefresh 在从API获取token后调用
+type Metrics interface {
+	ObserveRequest(info *RequestInfo, result *RequestResult)
+	ObserveTokenRefresh(appId string, err error)
+}
+
+// endpointTemplates 个推接口模板，":"开头的为路径参数
+var endpointTemplates = [][]string{
+	{"auth"},
+	{"push", "single", "cid"},
+	{"push", "single", "alias"},
+	{"push", "all"},
+	{"push", "tag"},
+	{"push", "fast_custom_tag"},
+	{"push", "list", "message"},
+	{"push", "list", "cid"},
+	{"task", ":taskid"},
+	{"task", "schedule", ":taskid"},
+	{"task", "detail", ":cid", ":taskid"},
+	{"user", "alias"},
+	{"user", "alias", ":alias"},
+	{"user", "alias", "cid", ":cid"},
+	{"user", "cid", "alias", ":alias"},
+	{"user", "custom_tag", "cid", ":cid"},
+	{"user", "custom_tag", "batch", ":tag"},
+	{"user", "status", ":cid"},
+	{"user", "detail", ":cid"},
+	{"user", "count"},
+	{"report", "push", "task", ":taskid"},
+	{"report", "push", "task_group", ":group_name"},
+	{"report", "push", "date", ":date"},
+	{"report", "user", "date", ":date"},
+	{"report", "online_user"},
+}
+
+// getEndpoint 返回请求路径对应的接口模板
+//
+//	path 格式为 {appId}/push/all，没有匹配的模板时返回去掉appId后的路径
+func getEndpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 {
+		segments = segments[1:]
+	}
+	for _, tpl := range endpointTemplates {
+		if matchEndpoint(tpl, segments) {
+			return "/" + strings.Join(tpl, "/")
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// matchEndpoint 路径是否匹配接口模板
+func matchEndpoint(tpl, segments []string) bool {
+	if len(tpl) != len(segments) {
+		return false
+	}
+	for i, s := range tpl {
+		if !strings.HasPrefix(s, ":") && s != segments[i] {
+			return false
+		}
+	}
+	return true
+}
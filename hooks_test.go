@@ -0,0 +1,222 @@
+package getuipush_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+	"github.com/zituocn/getui-push/models"
+)
+
+// ctxKey 测试中context的key
+type ctxKey string
+
+// traceCall 一次 Tracer.StartRequest 调用
+type traceCall struct {
+	ctx    context.Context
+	info   *getuipush.RequestInfo
+	result *getuipush.RequestResult //请求结束后设置
+}
+
+// recordTracer 记录所有调用的 Tracer
+type recordTracer struct {
+	mu    sync.Mutex
+	calls []*traceCall
+}
+
+func (r *recordTracer) StartRequest(ctx context.Context, info *getuipush.RequestInfo) (context.Context, func(result *getuipush.RequestResult)) {
+	call := &traceCall{ctx: ctx, info: info}
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+	return ctx, func(result *getuipush.RequestResult) {
+		r.mu.Lock()
+		call.result = result
+		r.mu.Unlock()
+	}
+}
+
+// last 返回最后一次调用
+func (r *recordTracer) last() *traceCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.calls) == 0 {
+		return nil
+	}
+	return r.calls[len(r.calls)-1]
+}
+
+// requestCall 一次 Metrics.ObserveRequest 调用
+type requestCall struct {
+	info   *getuipush.RequestInfo
+	result *getuipush.RequestResult
+}
+
+// recordMetrics 记录所有调用的 Metrics
+type recordMetrics struct {
+	mu       sync.Mutex
+	requests []*requestCall
+	tokens   []error
+}
+
+func (m *recordMetrics) ObserveRequest(info *getuipush.RequestInfo, result *getuipush.RequestResult) {
+	m.mu.Lock()
+	m.requests = append(m.requests, &requestCall{info: info, result: result})
+	m.mu.Unlock()
+}
+
+func (m *recordMetrics) ObserveTokenRefresh(appId string, err error) {
+	m.mu.Lock()
+	m.tokens = append(m.tokens, err)
+	m.mu.Unlock()
+}
+
+// last 返回最后一次 ObserveRequest
+func (m *recordMetrics) last() *requestCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.requests) == 0 {
+		return nil
+	}
+	return m.requests[len(m.requests)-1]
+}
+
+// failingServer 返回500的个推API，只有获取token的请求转发给s
+func failingServer(s *getuitest.Server) *httptest.Server {
+	target, _ := url.Parse(s.BaseURL())
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/auth") {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"msg":"server error"}`))
+	}))
+}
+
+func TestTracerAndMetrics(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	failing := failingServer(s)
+	defer failing.Close()
+	s.AddDevice("cid-0001", "android", "11000000")
+	payload := &models.CustomMessage{Title: "标题"}
+	parent := context.WithValue(context.Background(), ctxKey("trace"), "parent")
+
+	tests := []struct {
+		name      string
+		baseURL   string
+		errCode   int
+		close     bool
+		status    int
+		code      int
+		resultErr bool
+		wantErr   bool
+	}{
+		{name: "success", status: http.StatusOK, code: getuitest.CodeSuccess},
+		{name: "error code", errCode: getuitest.CodeParamError, status: http.StatusOK, code: getuitest.CodeParamError, wantErr: true},
+		{name: "http error", baseURL: failing.URL, status: http.StatusInternalServerError, code: -1, resultErr: true, wantErr: true},
+		{name: "request failed", close: true, status: 0, code: -1, resultErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer, metrics := &recordTracer{}, &recordMetrics{}
+			app := &getuipush.AppConfig{
+				BaseURL:    s.BaseURL(),
+				TokenStore: getuipush.NewMemoryTokenStore(),
+				Tracer:     tracer,
+				Metrics:    metrics,
+			}
+			if tt.baseURL != "" {
+				app.BaseURL = tt.baseURL
+			}
+			client, err := getuipush.NewPushClient(s.Config(), nil, app, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = client.GetToken(); err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics.tokens) != 1 || metrics.tokens[0] != nil {
+				t.Fatalf("ObserveTokenRefresh = %v, want one call without error", metrics.tokens)
+			}
+			if tt.errCode != 0 {
+				s.SetError("/push/single/cid", tt.errCode, "param error")
+				defer s.ClearErrors()
+			}
+			if tt.close {
+				s.Close()
+			}
+
+			_, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "cid-0001", payload, getuipush.WithContext(parent))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PushSingleByCid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			call := tracer.last()
+			if call == nil || call.info.Endpoint != "/push/single/cid" {
+				t.Fatalf("last StartRequest = %+v, want /push/single/cid", call)
+			}
+			if call.ctx.Value(ctxKey("trace")) != "parent" {
+				t.Fatal("StartRequest should receive the context from WithContext")
+			}
+			if call.result == nil {
+				t.Fatal("end func was not called")
+			}
+			observed := metrics.last()
+			if observed == nil || observed.info != call.info {
+				t.Fatalf("last ObserveRequest = %+v, want the request passed to the tracer", observed)
+			}
+			for name, result := range map[string]*getuipush.RequestResult{"tracer": call.result, "metrics": observed.result} {
+				if result.Status != tt.status || result.Code != tt.code {
+					t.Errorf("%s result status = %d code = %d, want %d %d", name, result.Status, result.Code, tt.status, tt.code)
+				}
+				if (result.Err != nil) != tt.resultErr {
+					t.Errorf("%s result error = %v, want error %v", name, result.Err, tt.resultErr)
+				}
+			}
+		})
+	}
+}
+
+func TestTracerContext(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid-0001", "android", "11000000")
+	tracer := &recordTracer{}
+	client, err := s.NewClient(&getuipush.AppConfig{Tracer: tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &models.CustomMessage{Title: "标题"}
+
+	// 不是推送的请求使用 context.Background()
+	if _, err = client.SearchStatus("cid-0001"); err != nil {
+		t.Fatal(err)
+	}
+	if call := tracer.last(); call == nil || call.ctx != context.Background() {
+		t.Fatalf("StartRequest without WithContext = %+v, want context.Background()", call)
+	}
+
+	// 取消的context不发送请求
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	before := len(s.Pushes())
+	if _, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "cid-0001", payload, getuipush.WithContext(canceled)); err == nil {
+		t.Fatal("PushSingleByCid with a canceled context should fail")
+	}
+	if n := len(s.Pushes()) - before; n != 0 {
+		t.Fatalf("server received %d pushes, want 0", n)
+	}
+	if call := tracer.last(); call.result == nil || call.result.Err == nil {
+		t.Fatalf("end func should get the error, got %+v", call.result)
+	}
+}
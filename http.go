@@ -2,12 +2,15 @@ package getuipush
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/zituocn/getui-push/models"
 	"io/ioutil"
 	"net/http"
+	neturl "net/url"
 	"time"
 
 	"github.com/tidwall/gjson"
@@ -15,12 +18,50 @@ import (
 
 var (
 	// ToDebug 全局的调试开关
+	//
+	//	为true时所有client都输出调试信息，单个client请使用 NewPushClient 的toDebug参数或 PushClient.SetDebug
 	ToDebug = false
 )
 
+// transport 请求API时使用的配置
+//
+//	每个PushClient根据自己的配置生成，见 PushClient.getTransport
+type transport struct {
+	baseURL string          //个推API地址，以/结尾
+	appId   string          //个推appId
+	logger  Logger          //日志
+	debug   bool            //是否输出请求和返回的详细内容
+	secrets []string        //需要在日志中隐藏的内容，如 masterSecret
+	tracer  Tracer          //链路追踪，可为nil
+	metrics Metrics         //监控指标，可为nil
+	ctx     context.Context //请求使用的context，见 WithContext
+
+	middlewares []Middleware //请求中间件，第一个在最外层
+}
+
+// defaultTransport 不属于某个PushClient时使用的配置
+func defaultTransport() *transport {
+	return &transport{
+		baseURL: APIURL,
+		logger:  defaultLogger,
+		debug:   ToDebug,
+		ctx:     context.Background(),
+	}
+}
+
 // RequestAPI 请求API，返回Response
 func RequestAPI(method, url, token string, bodyByte []byte) (*models.Response, error) {
-	data, err := HttpRequest(method, url, token, bodyByte)
+	return defaultTransport().requestAPI(method, url, token, bodyByte)
+}
+
+// HttpRequest 请求API,返回 []byte
+func HttpRequest(method, url, token string, bodyByte []byte) ([]byte, error) {
+	return defaultTransport().httpRequest(method, url, token, bodyByte)
+}
+
+// requestAPI 请求API，返回Response
+func (t *transport) requestAPI(method, url, token string, bodyByte []byte) (*models.Response, error) {
+	data, err := t.httpRequest(method, url, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -30,39 +71,97 @@ func RequestAPI(method, url, token string, bodyByte []byte) (*models.Response, e
 		return nil, fmt.Errorf("%s 请求接口 %s 返回错误代码: %s 信息: %s", NAME, method+" "+url, code, msg)
 	}
 	resp := &models.Response{
-		Code: int(gjson.GetBytes(data, "code").Int()),
-		Msg:  gjson.GetBytes(data, "msg").String(),
-		Data: gjson.GetBytes(data, "data").String(),
+		Code:   int(gjson.GetBytes(data, "code").Int()),
+		Msg:    gjson.GetBytes(data, "msg").String(),
+		Data:   gjson.GetBytes(data, "data").String(),
+		TaskId: gjson.GetBytes(data, "data.taskid").String(),
 	}
 	return resp, nil
 }
 
-// HttpRequest 请求API,返回 []byte
-func HttpRequest(method, url, token string, bodyByte []byte) ([]byte, error) {
-	u := APIURL + url
+// requestAPIData 请求API，并把返回的json解析到v中
+//
+//	code不为0时返回错误
+func (t *transport) requestAPIData(method, url, token string, bodyByte []byte, v interface{}) error {
+	data, err := t.httpRequest(method, url, token, bodyByte)
+	if err != nil {
+		return err
+	}
+	code := gjson.GetBytes(data, "code")
+	if code.Int() != 0 {
+		msg := gjson.GetBytes(data, "msg")
+		return fmt.Errorf("%s 请求接口 %s 返回错误代码: %s 信息: %s", NAME, method+" "+url, code, msg)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// httpRequest 请求API,返回 []byte
+//
+//	请求依次经过 AppConfig.Middlewares 和内置的日志、链路追踪、监控指标
+//	http状态码不为200时返回错误
+func (t *transport) httpRequest(method, url, token string, bodyByte []byte) ([]byte, error) {
+	header := make(http.Header)
+	header.Add("token", token)
+	header.Add("Content-Type", "application/json;charset=utf-8")
+	req := &Request{
+		AppId:    t.appId,
+		Method:   method,
+		Path:     url,
+		Endpoint: getEndpoint(url),
+		Header:   header,
+		Body:     bodyByte,
+		Ctx:      t.ctx,
+	}
+	resp, err := t.getDoer().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		//中间件是用户代码，可能返回 nil, nil
+		return nil, fmt.Errorf("%s 中间件返回的response为nil", NAME)
+	}
+	if resp.Status != http.StatusOK {
+		err = fmt.Errorf("%s response error , status: %d body: %s", NAME, resp.Status, t.redact(string(resp.Body)))
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// getDoer 返回经过中间件包装后的Doer
+//
+//	第一个中间件在最外层，内置的日志、链路追踪、监控指标在最内层
+func (t *transport) getDoer() Doer {
+	d := t.observe(DoerFunc(t.send))
+	for i := len(t.middlewares) - 1; i >= 0; i-- {
+		if t.middlewares[i] != nil {
+			d = t.middlewares[i](d)
+		}
+	}
+	return d
+}
+
+// send 发送http请求
+func (t *transport) send(req *Request) (*Response, error) {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 	client.Transport = getDefaultTransport()
-	body := bytes.NewBuffer(bodyByte)
-	req, err := http.NewRequest(method, u, body)
+	ctx := req.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	r, err := http.NewRequestWithContext(ctx, req.Method, t.baseURL+req.Path, bytes.NewBuffer(req.Body))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("token", token)
-	req.Header.Add("Content-Type", "application/json;charset=utf-8")
-	resp, err := client.Do(req)
-	if ToDebug {
-		fmt.Printf("\n")
-		fmt.Printf("DEBUG: \n")
-		fmt.Printf("-------------------------------------------------------------------------------------------------------------------------------------------------------\n")
-		debugPrint("Request Method", method)
-		debugPrint("Request URL", u)
-		debugPrint("Request Header", req.Header)
-		debugPrint("Request Body", "\n\t"+string(bodyByte))
-		fmt.Printf("\n")
-	}
+	r.Header = req.Header.Clone()
+	resp, err := client.Do(r)
 	if err != nil {
+		//url.Error 中含有完整的路径，替换为接口模板，避免appId、cid写入日志
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			err = fmt.Errorf("%s 请求接口 %s 失败: %s", NAME, req.Method+" "+req.Endpoint, urlErr.Err.Error())
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -70,44 +169,108 @@ func HttpRequest(method, url, token string, bodyByte []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if ToDebug {
-		debugPrint("Response Status", fmt.Sprintf("%d", resp.StatusCode))
-		debugPrint("Response Header", resp.Header)
-		debugPrint("Response Body", string(ret))
-		fmt.Printf("-------------------------------------------------------------------------------------------------------------------------------------------------------\n")
-		fmt.Printf("\n")
-	}
-	if resp != nil && resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("%s response error , status: %d body: %s", NAME, resp.StatusCode, string(ret))
-		return nil, err
-	}
-	return ret, nil
+	return NewResponse(resp.StatusCode, resp.Header, ret), nil
 }
 
-// makeReqBody 序列号v to json []byte
-func makeReqBody(v interface{}) ([]byte, error) {
-	if ToDebug {
-		body, err := json.MarshalIndent(v, "	", "	")
-		if err != nil {
-			return nil, err
+// observe 内置的中间件：日志、链路追踪、监控指标
+//
+//	请求失败时输出错误日志，调试模式下输出请求和返回的详细内容
+//	token 和 secrets 在写入日志前隐藏
+func (t *transport) observe(next Doer) Doer {
+	return DoerFunc(func(req *Request) (*Response, error) {
+		info := &RequestInfo{
+			AppId:     req.AppId,
+			Method:    req.Method,
+			Path:      req.Path,
+			Endpoint:  req.Endpoint,
+			RequestId: gjson.GetBytes(req.Body, "request_id").String(),
+			TaskId:    gjson.GetBytes(req.Body, "taskid").String(),
+		}
+		result := &RequestResult{Code: -1}
+		var end func(result *RequestResult)
+		if t.tracer != nil {
+			ctx := req.Ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			//包含span的context只用于本次请求，不修改中间件传入的req
+			traced := *req
+			traced.Ctx, end = t.tracer.StartRequest(ctx, info)
+			req = &traced
+		}
+
+		start := time.Now()
+		resp, err := next.Do(req)
+		if err == nil && resp == nil {
+			err = fmt.Errorf("%s 中间件返回的response为nil", NAME)
+		}
+		result.Latency = time.Since(start)
+		result.Err = err
+
+		// 使用接口模板，路径中的appId、cid、别名不写入日志
+		fields := []Field{
+			F("endpoint", req.Method+" "+req.Endpoint),
+			F("latency", result.Latency),
+		}
+		if info.RequestId != "" {
+			fields = append(fields, F("request_id", info.RequestId))
+		}
+		if info.TaskId != "" {
+			fields = append(fields, F("task_id", info.TaskId))
 		}
-		return body, nil
-	} else {
-		body, err := json.Marshal(v)
 		if err != nil {
-			return nil, err
+			t.logger.Log(LevelError, "请求接口失败", append(fields, F("error", t.redact(err.Error())))...)
+		} else {
+			result.Status = resp.Status
+			result.Code = resp.Code
+			result.TaskId = gjson.GetBytes(resp.Body, "data.taskid").String()
+			fields = append(fields, F("status", resp.Status))
+			if result.TaskId != "" {
+				fields = append(fields, F("task_id", result.TaskId))
+			}
+			if resp.Code >= 0 {
+				fields = append(fields, F("code", resp.Code))
+			}
+			if t.debug {
+				header := req.Header.Clone()
+				if header.Get("token") != "" {
+					header.Set("token", redacted)
+				}
+				t.logger.Log(LevelDebug, "请求接口", append(fields,
+					F("request_header", header),
+					F("request_body", t.redact(string(req.Body))),
+					F("response_header", resp.Header),
+					F("response_body", t.redact(string(resp.Body))),
+				)...)
+			}
+			if resp.Status != http.StatusOK {
+				result.Err = fmt.Errorf("%s response error , status: %d", NAME, resp.Status)
+				t.logger.Log(LevelError, "接口返回错误", fields...)
+			}
 		}
-		return body, nil
-	}
+
+		if t.metrics != nil {
+			t.metrics.ObserveRequest(info, result)
+		}
+		if end != nil {
+			end(result)
+		}
+		return resp, err
+	})
 }
 
-// debugPrint 打印调试信息
-func debugPrint(prefix string, v interface{}) {
-	fmt.Printf("%s: %v \n", leftText(prefix), v)
+// redact 隐藏日志中的token和secrets
+func (t *transport) redact(s string) string {
+	return redact(s, t.secrets...)
 }
 
-func leftText(s string) string {
-	return fmt.Sprintf("%20s", s)
+// makeReqBody 序列号v to json []byte
+func makeReqBody(v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
 }
 
 func getDefaultTransport() *http.Transport {
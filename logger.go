@@ -0,0 +1,189 @@
+package getuipush
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zituocn/logx"
+)
+
+var (
+	// defaultLogger 未设置 AppConfig.Logger 时使用的日志
+	defaultLogger Logger = NewLogxLogger(nil)
+
+	// secretJSONRegexp 日志中需要隐藏的json字段：token sign
+	secretJSONRegexp = regexp.MustCompile(`"(token|sign)"(\s*):(\s*)"[^"]*"`)
+)
+
+const (
+	// redacted 隐藏后的内容
+	redacted = "******"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota + 1 //调试信息，仅在调试模式下输出
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String 日志级别名称
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Field 结构化日志字段
+//
+//	常用的key：endpoint status latency request_id task_id code error
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F 返回一个日志字段
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger 日志接口
+//
+//	可使用 NewLogxLogger NewSlogLogger NewZapLogger 适配常用的日志库
+//	token 和 masterSecret 在写入日志前已隐藏
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...Field)
+}
+
+// SetDefaultLogger 设置默认的日志
+//
+//	用于未设置 AppConfig.Logger 的client，以及 RequestAPI HttpRequest
+func SetDefaultLogger(l Logger) {
+	if l != nil {
+		defaultLogger = l
+	}
+}
+
+/*
+logx
+*/
+
+// logxLogger logx适配
+type logxLogger struct {
+	l *logx.Logger
+}
+
+// NewLogxLogger 使用logx输出日志
+//
+//	l 为nil时使用logx的默认输出
+func NewLogxLogger(l *logx.Logger) Logger {
+	return &logxLogger{l: l}
+}
+
+func (x *logxLogger) Log(level LogLevel, msg string, fields ...Field) {
+	text := NAME + " " + msg + formatFields(fields)
+	if x.l == nil {
+		switch level {
+		case LevelDebug:
+			logx.Debug(text)
+		case LevelInfo:
+			logx.Info(text)
+		case LevelWarn:
+			logx.Warn(text)
+		default:
+			logx.Error(text)
+		}
+		return
+	}
+	switch level {
+	case LevelDebug:
+		x.l.Debug("%s", text)
+	case LevelInfo:
+		x.l.Info("%s", text)
+	case LevelWarn:
+		x.l.Warn("%s", text)
+	default:
+		x.l.Error("%s", text)
+	}
+}
+
+/*
+zap
+*/
+
+// ZapSugaredLogger zap.SugaredLogger 的方法
+//
+//	*zap.SugaredLogger 实现了此接口，本包不依赖zap
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// zapLogger zap适配
+type zapLogger struct {
+	l ZapSugaredLogger
+}
+
+// NewZapLogger 使用zap输出日志
+//
+//	logger.Sugar() 即可作为参数
+func NewZapLogger(l ZapSugaredLogger) Logger {
+	return &zapLogger{l: l}
+}
+
+func (z *zapLogger) Log(level LogLevel, msg string, fields ...Field) {
+	kv := make([]interface{}, 0, len(fields)*2)
+	for _, f := range fields {
+		kv = append(kv, f.Key, f.Value)
+	}
+	switch level {
+	case LevelDebug:
+		z.l.Debugw(msg, kv...)
+	case LevelInfo:
+		z.l.Infow(msg, kv...)
+	case LevelWarn:
+		z.l.Warnw(msg, kv...)
+	default:
+		z.l.Errorw(msg, kv...)
+	}
+}
+
+// formatFields 把字段格式化为 key=value
+func formatFields(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(" ")
+		b.WriteString(f.Key)
+		b.WriteString("=")
+		b.WriteString(fmt.Sprintf("%v", f.Value))
+	}
+	return b.String()
+}
+
+// redact 隐藏日志中的token sign 以及 secrets 中的内容
+func redact(s string, secrets ...string) string {
+	s = secretJSONRegexp.ReplaceAllString(s, `"$1"$2:$3"`+redacted+`"`)
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+//go:build go1.21
+// +build go1.21
+
+package getuipush
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger slog适配
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger 使用slog输出日志
+//
+//	l 为nil时使用 slog.Default()
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &slogLogger{l: l}
+}
+
+func (s *slogLogger) Log(level LogLevel, msg string, fields ...Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	s.l.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+// slogLevel 转换为slog的日志级别
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
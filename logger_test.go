@@ -0,0 +1,225 @@
+package getuipush_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+	"github.com/zituocn/getui-push/models"
+)
+
+// logEntry 一条日志
+type logEntry struct {
+	level  getuipush.LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+// captureLogger 记录所有日志的 Logger
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []*logEntry
+}
+
+func (l *captureLogger) Log(level getuipush.LogLevel, msg string, fields ...getuipush.Field) {
+	entry := &logEntry{level: level, msg: msg, fields: make(map[string]interface{}, len(fields))}
+	for _, f := range fields {
+		entry.fields[f.Key] = f.Value
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+// all 返回所有日志，格式化为字符串
+func (l *captureLogger) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := make([]string, 0, len(l.entries))
+	for _, e := range l.entries {
+		list = append(list, fmt.Sprintf("%s %s %v", e.level, e.msg, e.fields))
+	}
+	return list
+}
+
+// find 返回endpoint匹配的日志
+func (l *captureLogger) find(level getuipush.LogLevel, endpoint string) *logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.entries {
+		if e.level == level && e.fields["endpoint"] == endpoint {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("secret-cid-0001", "android", "11000000")
+	logger := &captureLogger{}
+	client, err := s.NewClient(&getuipush.AppConfig{Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetDebug(true)
+
+	token, err := client.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.PushSingleByCid(int(getuipush.ArticleMsg), "secret-cid-0001", &models.CustomMessage{Title: "标题"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.BindAlias(&models.Alias{Cid: "secret-cid-0001", Alias: "secret_alias"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.SearchCidByAlias("secret_alias"); err != nil {
+		t.Fatal(err)
+	}
+	s.SetError("*", 20001, "param error")
+	_, _ = client.SearchStatus("secret-cid-0001")
+
+	auth := logger.find(getuipush.LevelDebug, "POST /auth")
+	if auth == nil {
+		t.Fatalf("no debug log for /auth in %v", logger.all())
+	}
+	if body := fmt.Sprint(auth.fields["request_body"]); !strings.Contains(body, `"sign":"******"`) {
+		t.Errorf("auth request_body = %s, want sign redacted", body)
+	}
+	if body := fmt.Sprint(auth.fields["response_body"]); !strings.Contains(body, `"token":"******"`) {
+		t.Errorf("auth response_body = %s, want token redacted", body)
+	}
+
+	push := logger.find(getuipush.LevelDebug, "POST /push/single/cid")
+	if push == nil {
+		t.Fatalf("no debug log for /push/single/cid in %v", logger.all())
+	}
+	header, ok := push.fields["request_header"].(http.Header)
+	if !ok || header.Get("token") != "******" {
+		t.Errorf("request_header = %v, want token redacted", push.fields["request_header"])
+	}
+	if logger.find(getuipush.LevelDebug, "GET /user/cid/alias/:alias") == nil {
+		t.Errorf("alias lookup should be logged with the endpoint template, got %v", logger.all())
+	}
+	if logger.find(getuipush.LevelDebug, "GET /user/status/:cid") == nil {
+		t.Errorf("request with an error code should be logged with the endpoint template, got %v", logger.all())
+	}
+
+	// 请求失败时，错误信息中也不能有完整的路径
+	s.Close()
+	if _, err = client.SearchStatus("secret-cid-0001"); err == nil {
+		t.Fatal("SearchStatus after Close should fail")
+	}
+	failed := logger.find(getuipush.LevelError, "GET /user/status/:cid")
+	if failed == nil {
+		t.Fatalf("no error log for a failed request in %v", logger.all())
+	}
+	if msg := fmt.Sprint(failed.fields["error"]); strings.Contains(msg, "secret-cid-0001") || strings.Contains(msg, getuitest.AppId) {
+		t.Errorf("error log contains the request path: %s", msg)
+	}
+
+	// token 和 secrets 不能出现在任何日志中
+	secrets := map[string]string{
+		"token":         token,
+		"master secret": getuitest.MasterSecret,
+		"app secret":    getuitest.AppSecret,
+	}
+	for _, line := range logger.all() {
+		for name, secret := range secrets {
+			if strings.Contains(line, secret) {
+				t.Errorf("log contains %s: %s", name, line)
+			}
+		}
+	}
+	// endpoint 使用接口模板，不含appId、cid和别名
+	ids := map[string]string{
+		"app id": getuitest.AppId,
+		"cid":    "secret-cid-0001",
+		"alias":  "secret_alias",
+	}
+	for _, e := range logger.entries {
+		endpoint := fmt.Sprint(e.fields["endpoint"])
+		for name, id := range ids {
+			if strings.Contains(endpoint, id) {
+				t.Errorf("endpoint %q contains %s", endpoint, name)
+			}
+		}
+	}
+}
+
+func TestDebugPerClient(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid-0001", "android", "11000000")
+	debugLogger, quietLogger := &captureLogger{}, &captureLogger{}
+	debugClient, err := s.NewClient(&getuipush.AppConfig{Logger: debugLogger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	quietClient, err := s.NewClient(&getuipush.AppConfig{Logger: quietLogger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	debugClient.SetDebug(true)
+	payload := &models.CustomMessage{Title: "标题"}
+
+	push := func(client *getuipush.PushClient, opts ...getuipush.PushOption) {
+		t.Helper()
+		if _, err := client.PushSingleByCid(int(getuipush.ArticleMsg), "cid-0001", payload, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	debugCount := func(l *captureLogger) int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		n := 0
+		for _, e := range l.entries {
+			if e.level == getuipush.LevelDebug {
+				n++
+			}
+		}
+		return n
+	}
+
+	push(debugClient)
+	push(quietClient)
+	if debugCount(debugLogger) == 0 {
+		t.Fatal("debug client should write debug logs")
+	}
+	if n := debugCount(quietLogger); n != 0 {
+		t.Fatalf("quiet client wrote %d debug logs: %v", n, quietLogger.all())
+	}
+	if getuipush.ToDebug {
+		t.Fatal("SetDebug should not change ToDebug")
+	}
+	if quietClient.IsDebug() {
+		t.Fatal("SetDebug on one client should not change another")
+	}
+
+	// WithDebug 只对本次推送生效
+	push(quietClient, getuipush.WithDebug())
+	n := debugCount(quietLogger)
+	if n == 0 {
+		t.Fatal("WithDebug should write debug logs")
+	}
+	push(quietClient)
+	if got := debugCount(quietLogger); got != n {
+		t.Fatalf("WithDebug leaked into the next push: %d debug logs, want %d", got, n)
+	}
+	if quietClient.IsDebug() {
+		t.Fatal("WithDebug should not change the client")
+	}
+
+	debugClient.SetDebug(false)
+	n = debugCount(debugLogger)
+	push(debugClient)
+	if got := debugCount(debugLogger); got != n {
+		t.Fatalf("client wrote debug logs after SetDebug(false): %d, want %d", got, n)
+	}
+}
@@ -0,0 +1,80 @@
+package getuipush
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+)
+
+// Request 请求个推API的参数
+//
+//	中间件可以修改Header和Body，如注入header、加密请求内容
+type Request struct {
+	AppId    string          //个推appId
+	Method   string          //请求方法
+	Path     string          //请求路径，不含 APIURL，如 {appId}/push/all
+	Endpoint string          //接口模板，如 /push/all，见 RequestInfo.Endpoint
+	Header   http.Header     //请求头，含token
+	Body     []byte          //请求的json
+	Ctx      context.Context //发送http请求使用的context，见 WithContext
+}
+
+// Response 个推API的返回
+//
+//	Code Msg Data 由Body解析而来，修改Body后请使用 NewResponse 重新生成
+type Response struct {
+	Status int             //http状态码
+	Header http.Header     //返回头
+	Body   []byte          //返回的json
+	Code   int             //个推返回的code，无法解析时为-1
+	Msg    string          //个推返回的msg
+	Data   json.RawMessage //个推返回的data
+}
+
+// NewResponse 解析返回内容
+//
+//	可用于中间件中构造返回值，如故障演练时模拟个推的错误
+func NewResponse(status int, header http.Header, body []byte) *Response {
+	resp := &Response{
+		Status: status,
+		Header: header,
+		Body:   body,
+		Code:   -1,
+	}
+	if code := gjson.GetBytes(body, "code"); code.Exists() {
+		resp.Code = int(code.Int())
+	}
+	resp.Msg = gjson.GetBytes(body, "msg").String()
+	if data := gjson.GetBytes(body, "data"); data.Exists() {
+		resp.Data = json.RawMessage(data.Raw)
+	}
+	return resp
+}
+
+// Doer 发送请求
+type Doer interface {
+	Do(req *Request) (*Response, error)
+}
+
+// DoerFunc 把函数转换为Doer
+type DoerFunc func(req *Request) (*Response, error)
+
+// Do 调用f(req)
+func (f DoerFunc) Do(req *Request) (*Response, error) {
+	return f(req)
+}
+
+// Middleware 请求中间件
+//
+//	在 AppConfig.Middlewares 中设置，可用于审计、注入header、加密、重试、故障演练等
+//	func(next Doer) Doer {
+//		return DoerFunc(func(req *Request) (*Response, error) {
+//			// 请求前
+//			resp, err := next.Do(req)
+//			// 请求后
+//			return resp, err
+//		})
+//	}
+type Middleware func(next Doer) Doer
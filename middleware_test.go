@@ -0,0 +1,30 @@
+package getuipush_test
+
+import (
+	"strings"
+	"testing"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+)
+
+func TestMiddlewareNilResponse(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(&getuipush.AppConfig{
+		Middlewares: []getuipush.Middleware{
+			func(next getuipush.Doer) getuipush.Doer {
+				return getuipush.DoerFunc(func(req *getuipush.Request) (*getuipush.Response, error) {
+					return nil, nil
+				})
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.SearchStatus("cid1")
+	if err == nil || !strings.Contains(err.Error(), "中间件返回的response为nil") {
+		t.Fatalf("SearchStatus error = %v, want nil response error", err)
+	}
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 标签表达式的key
+const (
+	TagKeyPhoneType = "phone_type" //手机类型：android ios harmony
+	TagKeyRegion    = "region"     //地区编号，如 11000000
+	TagKeyCustomTag = "custom_tag" //自定义标签
+	TagKeyPortrait  = "portrait"   //个像标签
+)
+
+// 标签表达式values间的运算
+const (
+	TagOptOr  = "or"  //或
+	TagOptAnd = "and" //与
+	TagOptNot = "not" //非
+)
+
+// Audience 推送目标
+//
+//	cid、alias、tag、fast_custom_tag、all 根据推送接口选择其中一种
+//	使用 ByCids ByAliases ByTags ByFastTag All 构造
+//	@see http://docs.getui.com/getui/server/rest_v2/common_args/?id=doc-title-3
+type Audience struct {
+	Cid           []string `json:"cid,omitempty"`             //cid数组，单推时只能填一个
+	Alias         []string `json:"alias,omitempty"`           //别名数组，单推时只能填一个
+	Tag           []*Tag   `json:"tag,omitempty"`             //标签表达式，多个标签之间是交集的关系
+	FastCustomTag string   `json:"fast_custom_tag,omitempty"` //使用标签快速推送时的标签名
+
+	all bool //推送给所有人，序列化为 "all"
+}
+
+// ByCids 按cid推送
+func ByCids(cid ...string) *Audience {
+	return &Audience{Cid: cid}
+}
+
+// ByAliases 按别名推送
+func ByAliases(alias ...string) *Audience {
+	return &Audience{Alias: alias}
+}
+
+// ByTags 按标签表达式推送
+//
+//	多个标签之间是交集的关系
+func ByTags(tags ...*Tag) *Audience {
+	return &Audience{Tag: tags}
+}
+
+// ByFastTag 使用标签快速推送
+func ByFastTag(tag string) *Audience {
+	return &Audience{FastCustomTag: tag}
+}
+
+// All 推送给所有人
+func All() *Audience {
+	return &Audience{all: true}
+}
+
+// IsAll 是否推送给所有人
+func (a *Audience) IsAll() bool {
+	return a != nil && a.all
+}
+
+// Validate 校验推送目标
+//
+//	cid、alias、tag、fast_custom_tag、all 只能选择一种，且不能为空
+func (a *Audience) Validate() error {
+	if a == nil {
+		return errors.New("推送目标为空")
+	}
+	n := 0
+	if a.all {
+		n++
+	}
+	if a.Cid != nil {
+		n++
+		if err := checkValues("cid", a.Cid); err != nil {
+			return err
+		}
+	}
+	if a.Alias != nil {
+		n++
+		if err := checkValues("别名", a.Alias); err != nil {
+			return err
+		}
+	}
+	if a.Tag != nil {
+		n++
+		if len(a.Tag) == 0 {
+			return errors.New("标签表达式长度为0")
+		}
+		for _, tag := range a.Tag {
+			if err := tag.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if a.FastCustomTag != "" {
+		n++
+	}
+	if n == 0 {
+		return errors.New("推送目标为空")
+	}
+	if n > 1 {
+		return errors.New("cid、alias、tag、fast_custom_tag、all 只能选择一种")
+	}
+	return nil
+}
+
+// MarshalJSON 推送给所有人时序列化为 "all"
+func (a *Audience) MarshalJSON() ([]byte, error) {
+	if a.all {
+		return []byte(`"all"`), nil
+	}
+	type audience Audience
+	return json.Marshal((*audience)(a))
+}
+
+// UnmarshalJSON 支持 "all" 和对象两种格式
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var all string
+	if err := json.Unmarshal(b, &all); err == nil {
+		if all != "all" {
+			return fmt.Errorf("无效的推送目标: %s", all)
+		}
+		*a = Audience{all: true}
+		return nil
+	}
+	type audience Audience
+	return json.Unmarshal(b, (*audience)(a))
+}
+
+// NewTag 返回一个标签表达式
+//
+//	默认values之间是或的关系，可使用 And Not 修改
+func NewTag(key string, values ...string) *Tag {
+	return &Tag{
+		Key:     key,
+		Values:  values,
+		OptType: TagOptOr,
+	}
+}
+
+// TagPhoneType 按手机类型筛选：android ios harmony
+func TagPhoneType(values ...string) *Tag {
+	return NewTag(TagKeyPhoneType, values...)
+}
+
+// TagRegion 按地区编号筛选
+func TagRegion(values ...string) *Tag {
+	return NewTag(TagKeyRegion, values...)
+}
+
+// TagCustom 按自定义标签筛选
+func TagCustom(values ...string) *Tag {
+	return NewTag(TagKeyCustomTag, values...)
+}
+
+// TagPortrait 按个像标签筛选
+func TagPortrait(values ...string) *Tag {
+	return NewTag(TagKeyPortrait, values...)
+}
+
+// Or values之间是或的关系，满足其中一个即可
+func (t *Tag) Or() *Tag {
+	t.OptType = TagOptOr
+	return t
+}
+
+// And values之间是与的关系，需全部满足
+func (t *Tag) And() *Tag {
+	t.OptType = TagOptAnd
+	return t
+}
+
+// Not 排除values中的用户
+func (t *Tag) Not() *Tag {
+	t.OptType = TagOptNot
+	return t
+}
+
+// Validate 校验标签表达式
+func (t *Tag) Validate() error {
+	if t == nil {
+		return errors.New("标签表达式为空")
+	}
+	switch t.Key {
+	case TagKeyPhoneType, TagKeyRegion, TagKeyCustomTag, TagKeyPortrait:
+	default:
+		return fmt.Errorf("不支持的标签key: %s", t.Key)
+	}
+	switch t.OptType {
+	case TagOptOr, TagOptAnd, TagOptNot:
+	default:
+		return fmt.Errorf("标签 %s 不支持的opt_type: %s", t.Key, t.OptType)
+	}
+	return checkValues("标签 "+t.Key+" 的values", t.Values)
+}
+
+// checkValues 校验values不为空，且不含空字符串
+func checkValues(name string, values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("%s为空", name)
+	}
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("%s中含有空值", name)
+		}
+	}
+	return nil
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+)
+
 // Setting 配置
 // @https://docs.getui.com/getui/server/rest_v2/common_args/?id=doc-title-6
 // strategy:
@@ -71,8 +76,8 @@ type UPSNotification struct {
 // AndroidChannel android 厂商通道消息
 type AndroidChannel struct {
 	Ups struct {
-		Notification *UPSNotification `json:"notification"` //通知消息内容，与transmission、revoke 三选一，都填写时报错。若希望客户端离线时，直接在系统通知栏中展示通知栏消息，推荐使用此参数。
-		Transmission string           `json:"transmission"` //透传消息内容，与notification、revoke 三选一，都填写时报错，长度 ≤ 3072
+		Notification *UPSNotification `json:"notification,omitempty"` //通知消息内容，与transmission、revoke 三选一，都填写时报错。若希望客户端离线时，直接在系统通知栏中展示通知栏消息，推荐使用此参数。
+		Transmission string           `json:"transmission,omitempty"` //透传消息内容，与notification、revoke 三选一，都填写时报错，长度 ≤ 3072
 		Options      struct {
 			All struct {
 				Channel string `json:"channel"`
@@ -127,19 +132,105 @@ type WantData struct {
 	Parameters  interface{} `json:"parameters"`  //必须是 json 格式
 }
 
+// iOS 15 通知的中断级别
+const (
+	InterruptionPassive       = "passive"        //静默展示，不亮屏不响铃
+	InterruptionActive        = "active"         //默认级别
+	InterruptionTimeSensitive = "time-sensitive" //时效性通知，可突破专注模式
+	InterruptionCritical      = "critical"       //重要警告，需要苹果授权
+)
+
+// IOSChannel.Type 的取值
+const (
+	IOSTypeNotify = "notify" //apns通知消息，包括静默消息
+	IOSTypeVoIP   = "voip"   //voip语音推送，只有payload，没有aps
+)
+
 // IOSChannel ios厂商通道消息
 type IOSChannel struct {
-	Type    string `json:"type"`    //voip：voip语音推送，notify：apns通知消息；notify默认通知消息
-	Payload string `json:"payload"` //自定义消息内容
-	Aps     struct {
-		Alert struct {
-			Title string `json:"title"`
-			Body  string `json:"body"`
-		} `json:"alert"`
-		ContentAvailable int    `json:"content-available"` //0:表示普通通知 1:表示静默消息
-		Sound            string `json:"sound"`             //铃声，默认即可
-	} `json:"aps"` //推送通知消息内容
-	AutoBadge string `json:"auto_badge"` //用于计算icon上显示的数字，还可以实现显示数字的自动增减，如“+1”、 “-1”、 “1” 等，计算结果将覆盖badge
+	Type      string  `json:"type"`                 //voip：voip语音推送，notify：apns通知消息；notify默认通知消息
+	Payload   string  `json:"payload"`              //自定义消息内容
+	Aps       *IOSAps `json:"aps,omitempty"`        //推送通知消息内容，voip时为空
+	AutoBadge string  `json:"auto_badge,omitempty"` //用于计算icon上显示的数字，还可以实现显示数字的自动增减，如“+1”、 “-1”、 “1” 等，计算结果将覆盖badge；静默消息不修改badge
+}
+
+// IOSAps apns的aps
+//
+//	静默消息只有 content-available=1，不能有alert、sound和badge
+type IOSAps struct {
+	Alert             *IOSAlert `json:"alert,omitempty"`
+	ContentAvailable  int       `json:"content-available"`            //0:表示普通通知 1:表示静默消息
+	Sound             string    `json:"sound,omitempty"`              //铃声，默认即可
+	Category          string    `json:"category,omitempty"`           //通知类型，客户端据此展示操作按钮
+	ThreadId          string    `json:"thread-id,omitempty"`          //通知分组
+	MutableContent    int       `json:"mutable-content,omitempty"`    //1:允许 Notification Service Extension 修改通知，用于图片等富媒体
+	InterruptionLevel string    `json:"interruption-level,omitempty"` //iOS 15 中断级别：passive active time-sensitive critical
+	RelevanceScore    *float64  `json:"relevance-score,omitempty"`    //iOS 15 通知摘要中的排序，0-1之间；为nil时不设置，0也会下发
+}
+
+// IOSAlert apns通知的标题和内容
+//
+//	loc-key 等为多语言的key，对应客户端 Localizable.strings 中的内容
+type IOSAlert struct {
+	Title           string   `json:"title"`
+	Body            string   `json:"body"`
+	Subtitle        string   `json:"subtitle,omitempty"`          //副标题
+	LaunchImage     string   `json:"launch-image,omitempty"`      //点击通知启动时的启动图
+	TitleLocKey     string   `json:"title-loc-key,omitempty"`     //多语言标题的key
+	TitleLocArgs    []string `json:"title-loc-args,omitempty"`    //多语言标题的参数
+	SubtitleLocKey  string   `json:"subtitle-loc-key,omitempty"`  //多语言副标题的key
+	SubtitleLocArgs []string `json:"subtitle-loc-args,omitempty"` //多语言副标题的参数
+	LocKey          string   `json:"loc-key,omitempty"`           //多语言内容的key
+	LocArgs         []string `json:"loc-args,omitempty"`          //多语言内容的参数
+}
+
+// IOSOptions iOS通知的可选参数，见 Message.IOS
+type IOSOptions struct {
+	Subtitle          string   //副标题
+	LaunchImage       string   //启动图
+	Category          string   //通知类型，用于操作按钮
+	ThreadId          string   //通知分组
+	MutableContent    bool     //允许 Notification Service Extension 修改通知
+	InterruptionLevel string   //中断级别，见 InterruptionActive 等
+	RelevanceScore    *float64 //通知摘要中的排序，0-1之间，为nil时不设置
+	Sound             string   //铃声，为空时使用 default
+	TitleLocKey       string   //多语言标题的key
+	TitleLocArgs      []string //多语言标题的参数
+	SubtitleLocKey    string   //多语言副标题的key
+	SubtitleLocArgs   []string //多语言副标题的参数
+	LocKey            string   //多语言内容的key
+	LocArgs           []string //多语言内容的参数
+}
+
+// Apply 把可选参数设置到 IOSChannel 中
+//
+//	只用于通知消息，aps或alert为空(voip、静默消息)时不设置
+func (o *IOSOptions) Apply(ios *IOSChannel) {
+	if o == nil || ios == nil || ios.Aps == nil || ios.Aps.Alert == nil {
+		return
+	}
+	alert := ios.Aps.Alert
+	alert.Subtitle = o.Subtitle
+	alert.LaunchImage = o.LaunchImage
+	alert.TitleLocKey = o.TitleLocKey
+	alert.TitleLocArgs = o.TitleLocArgs
+	alert.SubtitleLocKey = o.SubtitleLocKey
+	alert.SubtitleLocArgs = o.SubtitleLocArgs
+	alert.LocKey = o.LocKey
+	alert.LocArgs = o.LocArgs
+	ios.Aps.Category = o.Category
+	ios.Aps.ThreadId = o.ThreadId
+	if o.MutableContent {
+		ios.Aps.MutableContent = 1
+	}
+	ios.Aps.InterruptionLevel = o.InterruptionLevel
+	if o.RelevanceScore != nil {
+		score := *o.RelevanceScore
+		ios.Aps.RelevanceScore = &score
+	}
+	if o.Sound != "" {
+		ios.Aps.Sound = o.Sound
+	}
 }
 
 // PushChannel 厂商通道消息
@@ -162,12 +253,12 @@ type PushMessage struct {
 
 // PushParam 推送上报参数
 type PushParam struct {
-	RequestId   string       `json:"request_id"`   //请求唯一标识号，10-32位之间；如果request_id重复，会导致消息丢失
-	GroupName   string       `json:"group_name"`   //任务组名。多个消息任务可以用同一个任务组名，后续可根据任务组名查询推送情况（长度限制100字符，且不能含有特殊符号）只允许填写数字、字母、横杠、下划线
-	Setting     *Setting     `json:"setting"`      //配置
-	Audience    interface{}  `json:"audience"`     //推送的目标用户，可能包括：cid,alias,tag,all等，根据具体情况动态;包括android|ios @see http://docs.getui.com/getui/server/rest_v2/common_args/?id=doc-title-3
-	PushMessage *PushMessage `json:"push_message"` //个推通道消息内容
-	PushChannel *PushChannel `json:"push_channel"` //厂商通道
+	RequestId   string       `json:"request_id"`         //请求唯一标识号，10-32位之间；如果request_id重复，会导致消息丢失
+	GroupName   string       `json:"group_name"`         //任务组名。多个消息任务可以用同一个任务组名，后续可根据任务组名查询推送情况（长度限制100字符，且不能含有特殊符号）只允许填写数字、字母、横杠、下划线
+	Setting     *Setting     `json:"setting"`            //配置
+	Audience    *Audience    `json:"audience,omitempty"` //推送的目标用户，可能包括：cid,alias,tag,all等，根据具体情况动态;包括android|ios @see http://docs.getui.com/getui/server/rest_v2/common_args/?id=doc-title-3
+	PushMessage *PushMessage `json:"push_message"`       //个推通道消息内容
+	PushChannel *PushChannel `json:"push_channel"`       //厂商通道
 }
 
 // CustomMessage 自定义的消息处理结构体
@@ -181,6 +272,38 @@ type CustomMessage struct {
 	MessageType  int64  `json:"message_type"`
 }
 
+// Message 推送的消息
+//
+//	Title Body 用于各厂商的通知，Payload 为透传给客户端的内容
+//	Payload 可以是任意能序列化为json的值；为string或[]byte时认为已经序列化，原样使用
+type Message struct {
+	Title   string      //通知标题
+	Body    string      //通知内容
+	Url     string      //点击通知后打开的页面，用于生成intent
+	Payload interface{} //透传内容，也是iOS的payload
+
+	IOS *IOSOptions //iOS通知的可选参数，如副标题、category、thread-id
+}
+
+// Transmission 返回透传内容
+func (m *Message) Transmission() (string, error) {
+	switch v := m.Payload.(type) {
+	case nil:
+		return "", errors.New("透传内容为空")
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+	b, err := json.Marshal(m.Payload)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 // Tag 自定义标签
 type Tag struct {
 	Key     string   `json:"key"`
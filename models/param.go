@@ -11,14 +11,45 @@ type Alias struct {
 	Alias string `json:"alias"`
 }
 
+// AliasBatchResult 批量绑定/解绑别名的结果
+type AliasBatchResult struct {
+	Responses []*Response     `json:"responses"` //每一批次的返回
+	Failed    []*AliasFailure `json:"failed"`    //校验或提交失败的cid/alias
+}
+
+// AliasFailure 处理失败的cid/alias
+type AliasFailure struct {
+	*Alias
+	Reason string `json:"reason"` //失败原因
+}
+
+// TagBatchResult 一批用户绑定/解绑标签的结果
+type TagBatchResult struct {
+	Responses []*Response   `json:"responses"` //每一批次的返回
+	Failed    []*TagFailure `json:"failed"`    //提交失败的批次
+}
+
+// TagFailure 提交失败的一批cid
+type TagFailure struct {
+	Cid    []string `json:"cid"`    //这一批的cid
+	Reason string   `json:"reason"` //失败原因
+}
+
 // Response 统一的返回值
 type Response struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
 	Data string `json:"data"` //此处把服务端返回的data处理成了json字串
+
+	TaskId string `json:"taskid,omitempty"` //群推、定时推送等返回的taskid，可用于查询或取消任务
 }
 
 // CustomTagsParam 一个用户绑定多个标签的参数
 type CustomTagsParam struct {
 	CustomTag []string `json:"custom_tag"`
 }
+
+// TagCidParam 一批用户绑定/解绑一个标签的参数
+type TagCidParam struct {
+	Cid []string `json:"cid"` //cid数组长度不能大于1000
+}
@@ -0,0 +1,93 @@
+package models
+
+// ReportStat 推送统计数据
+type ReportStat struct {
+	MsgNum     int64 `json:"msg_num"`     //可下发数
+	TargetNum  int64 `json:"target_num"`  //下发数
+	ReceiveNum int64 `json:"receive_num"` //接收数
+	DisplayNum int64 `json:"display_num"` //展示数
+	ClickNum   int64 `json:"click_num"`   //点击数
+}
+
+// Add 累加另一份统计数据
+//
+//	用于汇总多个任务的推送数据
+func (s *ReportStat) Add(o *ReportStat) {
+	if o == nil {
+		return
+	}
+	s.MsgNum += o.MsgNum
+	s.TargetNum += o.TargetNum
+	s.ReceiveNum += o.ReceiveNum
+	s.DisplayNum += o.DisplayNum
+	s.ClickNum += o.ClickNum
+}
+
+// PushReport 某个任务或任务组的推送数据
+// {
+//     "total":{
+//         "msg_num":100,
+//         "target_num":100,
+//         "receive_num":80,
+//         "display_num":60,
+//         "click_num":10
+//     },
+//     "detail":{
+//         "gt":{ ... },
+//         "hw":{ ... }
+//     },
+//     "actionCntMap":{
+//         "100":1
+//     }
+// }
+type PushReport struct {
+	Total        *ReportStat            `json:"total"`        //合计
+	Detail       map[string]*ReportStat `json:"detail"`       //各通道的数据，key为通道名称，如 gt hw xm
+	ActionCntMap map[string]int64       `json:"actionCntMap"` //自定义事件的次数，key为actionId
+}
+
+// PushReportResp 推送数据的返回值
+//
+//	data 的key为taskId或任务组名
+type PushReportResp struct {
+	Code int                    `json:"code"`
+	Msg  string                 `json:"msg"`
+	Data map[string]*PushReport `json:"data"`
+}
+
+// UserReport 单日用户数据
+// {
+//     "accumulative_num":1000,
+//     "register_num":10,
+//     "active_num":200,
+//     "online_num":50
+// }
+type UserReport struct {
+	AccumulativeNum int64 `json:"accumulative_num"` //累计用户数
+	RegisterNum     int64 `json:"register_num"`     //新增用户数
+	ActiveNum       int64 `json:"active_num"`       //活跃用户数
+	OnlineNum       int64 `json:"online_num"`       //在线用户数
+}
+
+// UserReportResp 单日用户数据的返回值
+//
+//	data 的key为日期，格式：yyyy-MM-dd
+type UserReportResp struct {
+	Code int                    `json:"code"`
+	Msg  string                 `json:"msg"`
+	Data map[string]*UserReport `json:"data"`
+}
+
+// OnlineUserReport 24小时在线用户数
+//
+//	online_statics 的key为毫秒时间戳，value为该时刻的在线用户数
+type OnlineUserReport struct {
+	OnlineStatics map[string]int64 `json:"online_statics"`
+}
+
+// OnlineUserResp 24小时在线用户数的返回值
+type OnlineUserResp struct {
+	Code int               `json:"code"`
+	Msg  string            `json:"msg"`
+	Data *OnlineUserReport `json:"data"`
+}
@@ -27,3 +27,26 @@ type TaskDetailResp struct {
 		} `json:"deatil"`
 	} `json:"data"`
 }
+
+// ScheduleTask 定时任务的状态
+// {
+//     "create_time":"1589423021000",
+//     "status":"success",
+//     "transmission_content":"",
+//     "push_time":"1589423321000"
+// }
+type ScheduleTask struct {
+	CreateTime          string `json:"create_time"`          //定时任务创建时间，毫秒时间戳
+	Status              string `json:"status"`               //定时任务状态：success(已下发)/failed(下发失败)/pending(待下发)
+	TransmissionContent string `json:"transmission_content"` //透传内容
+	PushTime            string `json:"push_time"`            //定时任务推送时间，毫秒时间戳
+}
+
+// ScheduleTaskResp 查询定时任务的返回值
+//
+//	data 的key为taskId
+type ScheduleTaskResp struct {
+	Code int                      `json:"code"`
+	Msg  string                   `json:"msg"`
+	Data map[string]*ScheduleTask `json:"data"`
+}
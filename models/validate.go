@@ -0,0 +1,328 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 个推文档中的长度限制
+const (
+	TransmissionMaxLength = 3072                 //透传消息内容的最大长度(字符)
+	IntentMaxLength       = 4096                 //intent的最大长度(字符)
+	VivoIntentMaxLength   = 1024                 //vivo通道intent的最大长度(字符)
+	CidMaxCount           = 1000                 //cid数组的最大长度
+	AliasMaxCount         = 1000                 //批量绑定/解绑别名时data_list的最大长度
+	AliasMaxLength        = 40                   //别名的最大长度(字节)，需小于此值
+	RequestIdMinLength    = 10                   //request_id的最小长度
+	RequestIdMaxLength    = 32                   //request_id的最大长度
+	GroupNameMaxLength    = 100                  //任务组名的最大长度
+	CustomTagMaxCount     = 100                  //一个用户最多绑定的标签数
+	CustomTagMaxLength    = 32                   //单个标签的最大长度(字符)
+	CustomTagMaxTotal     = 512                  //标签的最大总长度(字符)
+	TTLMax                = 3 * 24 * 3600 * 1000 //消息离线时间的最大值(毫秒)
+)
+
+var (
+	// aliasRegexp 别名的有效字符：字母、数字、下划线、汉字
+	aliasRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\p{Han}]+$`)
+
+	// groupNameRegexp 任务组名的有效字符：数字、字母、横杠、下划线
+	groupNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// FieldError 一个字段的校验错误
+type FieldError struct {
+	Field string //字段，如 push_message.transmission
+	Msg   string //错误信息
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Msg
+}
+
+// ValidationError 参数校验失败
+//
+//	包含所有不符合要求的字段
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msg := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msg = append(msg, fe.Error())
+	}
+	return "参数校验失败: " + strings.Join(msg, "; ")
+}
+
+// add 添加一个字段的错误
+func (e *ValidationError) add(field, format string, v ...interface{}) {
+	e.Errors = append(e.Errors, &FieldError{Field: field, Msg: fmt.Sprintf(format, v...)})
+}
+
+// err 没有错误时返回nil
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// CheckGroupName 校验任务组名
+//
+//	长度不超过100个字符，只允许填写数字、字母、横杠、下划线
+func CheckGroupName(groupName string) error {
+	if groupName == "" {
+		return errors.New("任务组名为空")
+	}
+	if len(groupName) > GroupNameMaxLength {
+		return fmt.Errorf("任务组名 %s 长度大于%d个字符", groupName, GroupNameMaxLength)
+	}
+	if !groupNameRegexp.MatchString(groupName) {
+		return fmt.Errorf("任务组名 %s 只允许填写数字、字母、横杠、下划线", groupName)
+	}
+	return nil
+}
+
+// Validate 校验推送参数
+//
+//	检查request_id、group_name、推送目标和消息内容的长度，返回所有不符合要求的字段
+//	vivo通道的intent不能超过1024个字符，Setting中vivo策略为3(只走个推通道)时不检查
+func (p *PushParam) Validate() error {
+	if p == nil {
+		return errors.New("推送参数为空")
+	}
+	ve := &ValidationError{}
+	n := len(p.RequestId)
+	if n < RequestIdMinLength || n > RequestIdMaxLength {
+		ve.add("request_id", "长度需在%d-%d之间，当前为%d", RequestIdMinLength, RequestIdMaxLength, n)
+	}
+	if p.GroupName != "" {
+		if err := CheckGroupName(p.GroupName); err != nil {
+			ve.add("group_name", "%s", err.Error())
+		}
+	}
+	if p.Setting != nil {
+		if p.Setting.TTL < -1 || p.Setting.TTL > TTLMax {
+			ve.add("setting.ttl", "需在-1到%d之间", TTLMax)
+		}
+	}
+	if p.Audience != nil {
+		if err := p.Audience.Validate(); err != nil {
+			ve.add("audience", "%s", err.Error())
+		}
+		if len(p.Audience.Cid) > CidMaxCount {
+			ve.add("audience.cid", "长度不能大于%d", CidMaxCount)
+		}
+	}
+
+	if p.PushMessage == nil {
+		ve.add("push_message", "个推通道消息为空")
+	} else {
+		p.PushMessage.validate(ve)
+	}
+
+	vivo := p.Setting == nil || p.Setting.Strategy.VV != 3
+	if c := p.PushChannel; c != nil {
+		if c.Android != nil {
+			ups := c.Android.Ups
+			checkLength(ve, "push_channel.android.ups.transmission", ups.Transmission, TransmissionMaxLength)
+			if ups.Notification != nil {
+				checkIntent(ve, "push_channel.android.ups.notification.intent", ups.Notification.Intent, vivo)
+			}
+		}
+		if c.IOS != nil {
+			c.IOS.validate(ve)
+		}
+		if c.Harmony != nil && c.Harmony.Notification != nil {
+			checkLength(ve, "push_channel.harmony.notification.payload", c.Harmony.Notification.Payload, TransmissionMaxLength)
+		}
+	}
+	return ve.err()
+}
+
+// validate 校验个推通道消息
+//
+//	notification、transmission、revoke 三选一
+func (m *PushMessage) validate(ve *ValidationError) {
+	n := 0
+	if m.Notification != nil {
+		n++
+		checkIntent(ve, "push_message.notification.intent", m.Notification.Intent, false)
+	}
+	if m.Transmission != "" {
+		n++
+		checkLength(ve, "push_message.transmission", m.Transmission, TransmissionMaxLength)
+	}
+	if m.Revoke != nil {
+		n++
+	}
+	if n != 1 {
+		ve.add("push_message", "notification、transmission、revoke 需三选一")
+	}
+}
+
+// validate 校验iOS通道
+//
+//	voip消息不能有aps；静默消息不能有alert、sound和badge
+func (c *IOSChannel) validate(ve *ValidationError) {
+	if c.Type == IOSTypeVoIP {
+		if c.Aps != nil {
+			ve.add("push_channel.ios.aps", "voip消息不能有aps")
+		}
+		return
+	}
+	if c.Aps == nil {
+		ve.add("push_channel.ios.aps", "为空")
+		return
+	}
+	if c.Aps.ContentAvailable == 1 {
+		if c.Aps.Alert != nil || c.Aps.Sound != "" || c.AutoBadge != "" {
+			ve.add("push_channel.ios.aps", "静默消息不能有alert、sound和badge")
+		}
+	}
+	switch c.Aps.InterruptionLevel {
+	case "", InterruptionPassive, InterruptionActive, InterruptionTimeSensitive, InterruptionCritical:
+	default:
+		ve.add("push_channel.ios.aps.interruption-level", "不支持的中断级别: %s", c.Aps.InterruptionLevel)
+	}
+	if s := c.Aps.RelevanceScore; s != nil && (*s < 0 || *s > 1) {
+		ve.add("push_channel.ios.aps.relevance-score", "需在0-1之间")
+	}
+}
+
+// Validate 校验按cid群推的参数
+func (p *PushListParam) Validate() error {
+	if p == nil {
+		return errors.New("群推参数为空")
+	}
+	ve := &ValidationError{}
+	if p.TaskId == "" {
+		ve.add("taskid", "为空")
+	}
+	checkCids(ve, "audience.cid", p.Audience.Cid)
+	return ve.err()
+}
+
+// ValidateCids 校验一次推送的cid列表
+//
+//	长度为1-1000，且不能含有空值
+func ValidateCids(cids []string) error {
+	ve := &ValidationError{}
+	checkCids(ve, "audience.cid", cids)
+	return ve.err()
+}
+
+// Validate 校验别名绑定参数
+//
+//	data_list 长度为1-1000，每一对cid和别名都需有效
+func (p *AliasParam) Validate() error {
+	if p == nil {
+		return errors.New("别名参数为空")
+	}
+	ve := &ValidationError{}
+	if len(p.DataList) == 0 {
+		ve.add("data_list", "为空")
+	}
+	if len(p.DataList) > AliasMaxCount {
+		ve.add("data_list", "长度不能大于%d", AliasMaxCount)
+	}
+	for i, item := range p.DataList {
+		if err := item.Validate(); err != nil {
+			ve.add(fmt.Sprintf("data_list[%d]", i), "%s", err.Error())
+		}
+	}
+	return ve.err()
+}
+
+// Validate 校验cid和别名
+//
+//	别名只能由字母、数字、下划线、汉字组成，长度小于40字节
+func (a *Alias) Validate() error {
+	if a == nil {
+		return errors.New("cid和别名为空")
+	}
+	if a.Cid == "" {
+		return errors.New("cid为空")
+	}
+	if a.Alias == "" {
+		return errors.New("别名为空")
+	}
+	if len(a.Alias) >= AliasMaxLength {
+		return fmt.Errorf("别名长度需小于%d字节", AliasMaxLength)
+	}
+	if !aliasRegexp.MatchString(a.Alias) {
+		return errors.New("别名只能由字母、数字、下划线、汉字组成")
+	}
+	return nil
+}
+
+// Validate 校验自定义标签
+//
+//	最多100个标签；单个标签长度最大为32字符，标签总长度最大为512个字符
+func (p *CustomTagsParam) Validate() error {
+	if p == nil {
+		return errors.New("自定义标签参数为空")
+	}
+	ve := &ValidationError{}
+	if len(p.CustomTag) == 0 {
+		ve.add("custom_tag", "为空")
+	}
+	if len(p.CustomTag) > CustomTagMaxCount {
+		ve.add("custom_tag", "长度不能大于%d", CustomTagMaxCount)
+	}
+	total := 0
+	for i, tag := range p.CustomTag {
+		n := utf8.RuneCountInString(tag)
+		if n == 0 {
+			ve.add(fmt.Sprintf("custom_tag[%d]", i), "为空")
+		}
+		if n > CustomTagMaxLength {
+			ve.add(fmt.Sprintf("custom_tag[%d]", i), "%s 长度大于%d个字符", tag, CustomTagMaxLength)
+		}
+		total += n
+	}
+	if total > CustomTagMaxTotal {
+		ve.add("custom_tag", "总长度大于%d个字符", CustomTagMaxTotal)
+	}
+	return ve.err()
+}
+
+// checkCids 校验cid数组，长度为1-1000且不含空值
+func checkCids(ve *ValidationError, field string, cids []string) {
+	if len(cids) == 0 {
+		ve.add(field, "为空")
+		return
+	}
+	if len(cids) > CidMaxCount {
+		ve.add(field, "长度不能大于%d", CidMaxCount)
+	}
+	for _, cid := range cids {
+		if cid == "" {
+			ve.add(field, "含有空值")
+			return
+		}
+	}
+}
+
+// checkLength 校验字段长度(字符)
+func checkLength(ve *ValidationError, field, s string, max int) {
+	if n := utf8.RuneCountInString(s); n > max {
+		ve.add(field, "长度不能大于%d，当前为%d", max, n)
+	}
+}
+
+// checkIntent 校验intent长度，vivo为true时按vivo通道的限制检查
+func checkIntent(ve *ValidationError, field, intent string, vivo bool) {
+	n := utf8.RuneCountInString(intent)
+	if n > IntentMaxLength {
+		ve.add(field, "长度不能大于%d，当前为%d", IntentMaxLength, n)
+		return
+	}
+	if vivo && n > VivoIntentMaxLength {
+		ve.add(field, "vivo通道长度不能大于%d，当前为%d", VivoIntentMaxLength, n)
+	}
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPushParamValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *PushParam)
+		fields []string //期望出错的字段，为空时期望校验通过
+	}{
+		{
+			name:   "valid",
+			modify: func(p *PushParam) {},
+		},
+		{
+			name:   "request_id min length",
+			modify: func(p *PushParam) { p.RequestId = strings.Repeat("a", RequestIdMinLength) },
+		},
+		{
+			name:   "request_id below min length",
+			modify: func(p *PushParam) { p.RequestId = strings.Repeat("a", RequestIdMinLength-1) },
+			fields: []string{"request_id"},
+		},
+		{
+			name:   "request_id max length",
+			modify: func(p *PushParam) { p.RequestId = strings.Repeat("a", RequestIdMaxLength) },
+		},
+		{
+			name:   "request_id above max length",
+			modify: func(p *PushParam) { p.RequestId = strings.Repeat("a", RequestIdMaxLength+1) },
+			fields: []string{"request_id"},
+		},
+		{
+			name:   "group_name max length",
+			modify: func(p *PushParam) { p.GroupName = strings.Repeat("g", GroupNameMaxLength) },
+		},
+		{
+			name:   "group_name above max length",
+			modify: func(p *PushParam) { p.GroupName = strings.Repeat("g", GroupNameMaxLength+1) },
+			fields: []string{"group_name"},
+		},
+		{
+			name:   "transmission max length",
+			modify: func(p *PushParam) { p.PushMessage.Transmission = strings.Repeat("透", TransmissionMaxLength) },
+		},
+		{
+			name:   "transmission above max length",
+			modify: func(p *PushParam) { p.PushMessage.Transmission = strings.Repeat("透", TransmissionMaxLength+1) },
+			fields: []string{"push_message.transmission"},
+		},
+		{
+			name: "ups transmission above max length",
+			modify: func(p *PushParam) {
+				p.PushChannel.Android.Ups.Transmission = strings.Repeat("a", TransmissionMaxLength+1)
+			},
+			fields: []string{"push_channel.android.ups.transmission"},
+		},
+		{
+			name: "intent max length",
+			modify: func(p *PushParam) {
+				p.Setting.Strategy.VV = 3
+				p.PushChannel.Android.Ups.Notification.Intent = strings.Repeat("i", IntentMaxLength)
+			},
+		},
+		{
+			name: "intent above max length",
+			modify: func(p *PushParam) {
+				p.Setting.Strategy.VV = 3
+				p.PushChannel.Android.Ups.Notification.Intent = strings.Repeat("i", IntentMaxLength+1)
+			},
+			fields: []string{"push_channel.android.ups.notification.intent"},
+		},
+		{
+			name: "vivo intent max length",
+			modify: func(p *PushParam) {
+				p.PushChannel.Android.Ups.Notification.Intent = strings.Repeat("i", VivoIntentMaxLength)
+			},
+		},
+		{
+			name: "vivo intent above max length",
+			modify: func(p *PushParam) {
+				p.PushChannel.Android.Ups.Notification.Intent = strings.Repeat("i", VivoIntentMaxLength+1)
+			},
+			fields: []string{"push_channel.android.ups.notification.intent"},
+		},
+		{
+			name:   "cid max count",
+			modify: func(p *PushParam) { p.Audience = ByCids(makeCids(CidMaxCount)...) },
+		},
+		{
+			name:   "cid above max count",
+			modify: func(p *PushParam) { p.Audience = ByCids(makeCids(CidMaxCount + 1)...) },
+			fields: []string{"audience.cid"},
+		},
+		{
+			name: "multiple violations",
+			modify: func(p *PushParam) {
+				p.RequestId = "short"
+				p.GroupName = strings.Repeat("g", GroupNameMaxLength+1)
+				p.Audience = ByCids(makeCids(CidMaxCount + 1)...)
+				p.PushMessage.Transmission = strings.Repeat("a", TransmissionMaxLength+1)
+				p.PushChannel.Android.Ups.Transmission = strings.Repeat("a", TransmissionMaxLength+1)
+				p.PushChannel.Android.Ups.Notification.Intent = strings.Repeat("i", VivoIntentMaxLength+1)
+			},
+			fields: []string{
+				"request_id",
+				"group_name",
+				"audience.cid",
+				"push_message.transmission",
+				"push_channel.android.ups.transmission",
+				"push_channel.android.ups.notification.intent",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := validPushParam()
+			tt.modify(p)
+			checkFields(t, p.Validate(), tt.fields)
+		})
+	}
+}
+
+func TestPushListParamValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		taskId string
+		cids   []string
+		fields []string
+	}{
+		{name: "valid", taskId: "task", cids: []string{"cid1"}},
+		{name: "cid max count", taskId: "task", cids: makeCids(CidMaxCount)},
+		{name: "cid above max count", taskId: "task", cids: makeCids(CidMaxCount + 1), fields: []string{"audience.cid"}},
+		{name: "empty cid", taskId: "task", cids: []string{"cid1", ""}, fields: []string{"audience.cid"}},
+		{name: "multiple violations", cids: nil, fields: []string{"taskid", "audience.cid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PushListParam{TaskId: tt.taskId}
+			p.Audience.Cid = tt.cids
+			checkFields(t, p.Validate(), tt.fields)
+		})
+	}
+}
+
+// validPushParam 返回一个可以通过校验的推送参数
+func validPushParam() *PushParam {
+	android := &AndroidChannel{}
+	android.Ups.Notification = &UPSNotification{Title: "title", Body: "body", ClickType: "intent", Intent: "intent"}
+	return &PushParam{
+		RequestId:   "1234567890",
+		Setting:     &Setting{TTL: TTLMax},
+		Audience:    ByCids("cid1"),
+		PushMessage: &PushMessage{Transmission: "{}"},
+		PushChannel: &PushChannel{Android: android},
+	}
+}
+
+func makeCids(n int) []string {
+	cids := make([]string, n)
+	for i := range cids {
+		cids[i] = fmt.Sprintf("cid%d", i)
+	}
+	return cids
+}
+
+// checkFields 检查err中出错的字段与fields一致
+func checkFields(t *testing.T, err error, fields []string) {
+	t.Helper()
+	if len(fields) == 0 {
+		if err != nil {
+			t.Fatalf("Validate() error: %v", err)
+		}
+		return
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	got := make([]string, 0, len(ve.Errors))
+	for _, fe := range ve.Errors {
+		got = append(got, fe.Field)
+	}
+	if !reflect.DeepEqual(got, fields) {
+		t.Fatalf("Validate() fields = %v, want %v (%v)", got, fields, err)
+	}
+}
@@ -5,14 +5,27 @@ import (
 	"github.com/zituocn/getui-push/models"
 )
 
+// 推送接口的路径，不含appId
+//
+//	演练模式记录的路径也使用这些常量，与实际发送的请求保持一致
+const (
+	pathPushSingleCid     = "/push/single/cid"
+	pathPushSingleAlias   = "/push/single/alias"
+	pathPushAll           = "/push/all"
+	pathPushTag           = "/push/tag"
+	pathPushFastCustomTag = "/push/fast_custom_tag"
+	pathPushListMessage   = "/push/list/message"
+	pathPushListCid       = "/push/list/cid"
+)
+
 // pushSingleByCid 推送给单个用户
 //	cid在param中设置
-func pushSingleByCid(appId, token string, param *models.PushParam) (*models.Response, error) {
+func pushSingleByCid(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/single/cid", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushSingleCid, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -21,12 +34,12 @@ func pushSingleByCid(appId, token string, param *models.PushParam) (*models.Resp
 
 // pushSingleByAlias 推送给单个用户
 //	alias在param中设置
-func pushSingleByAlias(appId, token string, param *models.PushParam) (*models.Response, error) {
+func pushSingleByAlias(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/single/alias", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushSingleAlias, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -34,12 +47,12 @@ func pushSingleByAlias(appId, token string, param *models.PushParam) (*models.Re
 }
 
 // pushApp 推给所有
-func pushApp(appId, token string, param *models.PushParam) (*models.Response, error) {
+func pushApp(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/all", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushAll, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -49,12 +62,12 @@ func pushApp(appId, token string, param *models.PushParam) (*models.Response, er
 // pushAppByClient 推给不同客户端
 //	客户端指android或ios
 //	是android还是ios，从param中区别
-func pushAppByClient(appId, token string, param *models.PushParam) (*models.Response, error) {
+func pushAppByClient(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/tag", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushTag, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -63,12 +76,12 @@ func pushAppByClient(appId, token string, param *models.PushParam) (*models.Resp
 
 // pushAppByTag 推给不同的tag
 //	自定义tag
-func pushAppByTag(appId, token string, param *models.PushParam) (*models.Response, error) {
+func pushAppByTag(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/tag", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushTag, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -76,12 +89,12 @@ func pushAppByTag(appId, token string, param *models.PushParam) (*models.Respons
 }
 
 // pushAppByFastCustomTag 使用标签快速推送
-func pushAppByFastCustomTag(appId, token string, param *models.PushParam) (*models.Response, error) {
+func pushAppByFastCustomTag(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/fast_custom_tag", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushFastCustomTag, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -90,31 +103,32 @@ func pushAppByFastCustomTag(appId, token string, param *models.PushParam) (*mode
 
 // createPushMessage 此接口用来创建消息体，并返回taskid，为批量推的前置步骤
 //	taskid 任务编号，用于执行cid批量推和执行别名批量推，此taskid可以多次使用，有效期为离线时间
-func createPushMessage(appId, token string, param *models.PushParam) (*models.Response, error) {
+func createPushMessage(t *transport, appId, token string, param *models.PushParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	b, err := HttpRequest("POST", appId+"/push/list/message", token, bodyByte)
+	b, err := t.httpRequest("POST", appId+pathPushListMessage, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
 	resp := &models.Response{
-		Code: int(gjson.GetBytes(b, "code").Int()),
-		Msg:  gjson.GetBytes(b, "msg").String(),
-		Data: gjson.GetBytes(b, "data.taskid").String(),
+		Code:   int(gjson.GetBytes(b, "code").Int()),
+		Msg:    gjson.GetBytes(b, "msg").String(),
+		Data:   gjson.GetBytes(b, "data.taskid").String(),
+		TaskId: gjson.GetBytes(b, "data.taskid").String(),
 	}
 	return resp, nil
 }
 
 // pushListByCid 按cid群推
 //	使用前，请先调用 CreatePushMessage 后返回的taskid
-func pushListByCid(appId, token string, param *models.PushListParam) (*models.Response, error) {
+func pushListByCid(t *transport, appId, token string, param *models.PushListParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/push/list/cid", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+pathPushListCid, token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -123,8 +137,19 @@ func pushListByCid(appId, token string, param *models.PushListParam) (*models.Re
 
 // stopTask 停止任务
 //	对正处于推送状态，或者未接收的消息停止下发（只支持批量推和群推任务）
-func stopTask(appId, token, taskId string) (*models.Response, error) {
-	resp, err := RequestAPI("DELETE", appId+"/task/"+taskId, token, nil)
+func stopTask(t *transport, appId, token, taskId string) (*models.Response, error) {
+	resp, err := t.requestAPI("DELETE", appId+"/task/"+taskId, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// deleteSchedule 删除定时任务
+//
+//	用来删除还未下发的定时任务
+func deleteSchedule(t *transport, appId, token, taskId string) (*models.Response, error) {
+	resp, err := t.requestAPI("DELETE", appId+"/task/schedule/"+taskId, token, nil)
 	if err != nil {
 		return nil, err
 	}
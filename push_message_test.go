@@ -0,0 +1,259 @@
+package getuipush_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestPushMessageWireFormat(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := func() *models.Message {
+		return &models.Message{Title: "标题", Body: "内容", Url: "/home", Payload: `{"type":"sync"}`}
+	}
+
+	tests := []struct {
+		name    string
+		push    func(dryRun *getuipush.DryRun) error
+		present []string               //必须存在的字段
+		absent  []string               //不能存在的字段
+		equal   map[string]interface{} //字段的值
+	}{
+		{
+			name: "notify",
+			push: func(dryRun *getuipush.DryRun) error {
+				_, err := client.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid1"), message(), getuipush.WithDryRun(dryRun))
+				return err
+			},
+			present: []string{
+				"push_message.transmission",
+				"push_channel.ios.aps.alert",
+				"push_channel.android.ups.notification",
+			},
+			absent: []string{
+				"push_message.notification",
+				"push_message.revoke",
+				"push_channel.android.ups.transmission",
+			},
+			equal: map[string]interface{}{
+				"push_channel.ios.type":                       models.IOSTypeNotify,
+				"push_channel.ios.auto_badge":                 "+1",
+				"push_channel.ios.aps.content-available":      0.0,
+				"push_channel.ios.aps.sound":                  "default",
+				"push_channel.ios.aps.alert.title":            "标题",
+				"push_channel.ios.aps.alert.body":             "内容",
+				"push_channel.android.ups.notification.title": "标题",
+			},
+		},
+		{
+			name: "silent",
+			push: func(dryRun *getuipush.DryRun) error {
+				_, err := client.PushSilent(models.ByCids("cid1"), message(), getuipush.WithDryRun(dryRun))
+				return err
+			},
+			present: []string{"push_message.transmission"},
+			absent: []string{
+				"push_channel.ios.auto_badge",
+				"push_channel.android.ups.notification",
+				"push_channel.harmony",
+			},
+			equal: map[string]interface{}{
+				"push_channel.ios.type":                 models.IOSTypeNotify,
+				"push_channel.ios.aps":                  map[string]interface{}{"content-available": 1.0},
+				"push_channel.android.ups.transmission": `{"type":"sync"}`,
+			},
+		},
+		{
+			name: "voip",
+			push: func(dryRun *getuipush.DryRun) error {
+				_, err := client.PushVoIP(int(getuipush.InstantMsg), models.ByCids("cid1"), message(), getuipush.WithDryRun(dryRun))
+				return err
+			},
+			present: []string{"push_channel.android.ups.notification"},
+			absent: []string{
+				"push_channel.ios.aps",
+				"push_channel.ios.auto_badge",
+			},
+			equal: map[string]interface{}{
+				"push_channel.ios.type":    models.IOSTypeVoIP,
+				"push_channel.ios.payload": `{"type":"sync"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRun := getuipush.NewDryRun()
+			if err := tt.push(dryRun); err != nil {
+				t.Fatal(err)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(dryRun.Last().Body, &body); err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range tt.present {
+				if _, ok := lookup(body, path); !ok {
+					t.Errorf("%s is missing in %s", path, dryRun.Last().Body)
+				}
+			}
+			for _, path := range tt.absent {
+				if v, ok := lookup(body, path); ok {
+					t.Errorf("%s = %v, want absent", path, v)
+				}
+			}
+			for path, want := range tt.equal {
+				if got, _ := lookup(body, path); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %#v, want %#v", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPushMessageRejectsSchedule(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &models.Message{Title: "标题", Body: "内容", Payload: "{}"}
+	at := getuipush.WithScheduleTime(time.Now().Add(time.Hour))
+
+	if _, err = client.PushVoIP(int(getuipush.InstantMsg), models.All(), message, at); err == nil {
+		t.Error("PushVoIP with WithScheduleTime should fail")
+	}
+	if _, err = client.PushSilent(models.All(), message, at); err == nil {
+		t.Error("PushSilent with WithScheduleTime should fail")
+	}
+	if n := len(s.Pushes()); n != 0 {
+		t.Fatalf("server received %d pushes, want 0", n)
+	}
+}
+
+func TestScheduleOption(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	s.AddDevice("cid-0001", "android", "11000000")
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := getuitest.NewFake()
+	services := []struct {
+		name  string
+		svc   getuipush.PushService
+		count func() int
+	}{
+		{name: "client", svc: client, count: func() int { return len(s.Pushes()) }},
+		{name: "fake", svc: fake, count: func() int { return len(fake.Pushes()) }},
+	}
+	payload := &models.CustomMessage{Title: "标题", Content: "内容"}
+	message := &models.Message{Title: "标题", Body: "内容", Payload: "{}"}
+	at := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	opt := getuipush.WithScheduleTime(at)
+
+	// 只有 PushMessage 使用 WithScheduleTime，其他方法设置时返回错误，不能立即推送
+	rejected := []struct {
+		name string
+		push func(svc getuipush.PushService) error
+		want error
+	}{
+		{name: "PushAll", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushAll(int(getuipush.ArticleMsg), 0, payload, opt)
+			return err
+		}},
+		{name: "PushAllByClients", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushAllByClients(int(getuipush.ArticleMsg), 0, []getuipush.ClientType{getuipush.Android}, payload, opt)
+			return err
+		}},
+		{name: "PushAllByCustomTag", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushAllByCustomTag(int(getuipush.ArticleMsg), 0, []string{"vip"}, payload, opt)
+			return err
+		}},
+		{name: "PushAllByLogicTags", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushAllByLogicTags(int(getuipush.ArticleMsg), 0, []*models.Tag{models.TagRegion("11000000")}, payload, opt)
+			return err
+		}},
+		{name: "PushAppByFastCustomTag", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushAppByFastCustomTag(int(getuipush.ArticleMsg), 0, "vip", payload, opt)
+			return err
+		}},
+		{name: "PushSingleByCid", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushSingleByCid(int(getuipush.ArticleMsg), "cid-0001", payload, opt)
+			return err
+		}},
+		{name: "PushListByCid", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushListByCid(int(getuipush.ArticleMsg), []string{"cid-0001"}, payload, opt)
+			return err
+		}},
+		{name: "PushListMessage", want: getuipush.ErrScheduleOption, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushListMessage(int(getuipush.ArticleMsg), []string{"cid-0001"}, message, opt)
+			return err
+		}},
+		{name: "PushMessage to cid", want: getuipush.ErrScheduleNotSupported, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushMessage(int(getuipush.ArticleMsg), models.ByCids("cid-0001"), message, opt)
+			return err
+		}},
+		{name: "PushVoIP", want: getuipush.ErrScheduleNotSupported, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushVoIP(int(getuipush.InstantMsg), models.All(), message, opt)
+			return err
+		}},
+		{name: "PushSilent", want: getuipush.ErrScheduleNotSupported, push: func(svc getuipush.PushService) error {
+			_, err := svc.PushSilent(models.All(), message, opt)
+			return err
+		}},
+	}
+	for _, svc := range services {
+		t.Run(svc.name, func(t *testing.T) {
+			for _, tt := range rejected {
+				before := svc.count()
+				if err := tt.push(svc.svc); !errors.Is(err, tt.want) {
+					t.Errorf("%s error = %v, want %v", tt.name, err, tt.want)
+				}
+				if n := svc.count() - before; n != 0 {
+					t.Errorf("%s recorded %d pushes, want 0", tt.name, n)
+				}
+			}
+
+			resp, err := svc.svc.PushMessage(int(getuipush.ArticleMsg), models.All(), message, opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, err := svc.svc.GetScheduleTask(resp.TaskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strconv.FormatInt(at.UnixNano()/int64(time.Millisecond), 10); task.PushTime != want {
+				t.Fatalf("schedule task push_time = %s, want %s", task.PushTime, want)
+			}
+		})
+	}
+}
+
+// lookup 按 a.b.c 的路径取出json中的值
+func lookup(body map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = body
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,205 @@
+package getuipush
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+// PushOption 单次推送的可选参数
+type PushOption func(o *pushOptions)
+
+// pushOptions 单次推送的参数
+type pushOptions struct {
+	groupName      string          //任务组名
+	safetyLimit    int64           //预估用户数上限
+	hasSafetyLimit bool            //是否设置了 safetyLimit
+	dryRun         *DryRun         //演练模式的记录器
+	debug          bool            //本次推送是否输出调试信息
+	ctx            context.Context //本次推送请求使用的context
+
+	scheduleTime   time.Time          //定时推送的时间，只用于 PushMessage
+	kind           pushKind           //iOS消息的类型，由 PushVoIP、PushSilent 设置
+	truncate       *TruncatePolicy    //通知标题和内容的截断策略
+	hasTruncate    bool               //是否设置了 truncate
+	truncateReport *TruncateReport    //截断记录
+	ios            *models.IOSOptions //iOS通知的可选参数
+}
+
+// pushKind iOS消息的类型
+type pushKind int
+
+const (
+	kindNotify pushKind = iota //通知消息
+	kindVoIP                   //voip语音推送
+	kindSilent                 //静默消息，content-available=1
+)
+
+// WithGroupName 设置本次推送的任务组名
+//
+//	后续可根据任务组名查询推送情况，见 ReportPushGroup
+func WithGroupName(groupName string) PushOption {
+	return func(o *pushOptions) {
+		o.groupName = groupName
+	}
+}
+
+// WithSafetyLimit 设置本次推送的预估用户数上限
+//
+//	按标签或推送给所有人时，预估用户数超过limit则拒绝推送，返回 *SafetyLimitError
+//	覆盖 AppConfig.SafetyLimit，为0时不检查
+func WithSafetyLimit(limit int64) PushOption {
+	return func(o *pushOptions) {
+		o.safetyLimit = limit
+		o.hasSafetyLimit = true
+	}
+}
+
+// WithDryRun 本次推送使用演练模式
+//
+//	只构造并校验推送参数，不发送请求，请求记录在 dryRun 中
+func WithDryRun(dryRun *DryRun) PushOption {
+	return func(o *pushOptions) {
+		o.dryRun = dryRun
+	}
+}
+
+// WithDebug 本次推送输出调试信息
+//
+//	只影响本次推送的请求，不修改client的调试模式
+func WithDebug() PushOption {
+	return func(o *pushOptions) {
+		o.debug = true
+	}
+}
+
+// WithContext 设置本次推送请求使用的context
+//
+//	传给 Tracer.StartRequest，可用于链路追踪和取消请求
+func WithContext(ctx context.Context) PushOption {
+	return func(o *pushOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithScheduleTime 设置 PushMessage 定时推送的时间
+//
+//	必须是当前时间之后，7天之内的时间，否则返回 *ScheduleTimeError
+//	其他推送方法设置时返回 ErrScheduleOption，定时推送请使用 scheduleTime 参数或 PushAllAt 等方法
+func WithScheduleTime(t time.Time) PushOption {
+	return func(o *pushOptions) {
+		o.scheduleTime = t
+	}
+}
+
+// WithTruncate 本次推送按 policy 截断通知的标题和内容
+//
+//	覆盖 AppConfig.Truncate，policy为nil时本次推送不截断
+func WithTruncate(policy *TruncatePolicy) PushOption {
+	return func(o *pushOptions) {
+		o.truncate = policy
+		o.hasTruncate = true
+	}
+}
+
+// WithTruncateReport 把本次推送截断的内容记录到 report 中
+func WithTruncateReport(report *TruncateReport) PushOption {
+	return func(o *pushOptions) {
+		o.truncateReport = report
+	}
+}
+
+// WithIOS 设置本次推送iOS通知的可选参数，如副标题、category、thread-id
+//
+//	用于 PushSingleByCid 等使用 CustomMessage 的接口；Message.IOS 不为nil时以 Message.IOS 为准
+//	voip和静默消息没有alert，不会设置
+func WithIOS(ios *models.IOSOptions) PushOption {
+	return func(o *pushOptions) {
+		o.ios = ios
+	}
+}
+
+// checkNoSchedule 不支持 WithScheduleTime 的推送方法调用
+//
+//	避免设置的定时被忽略而立即推送
+func (o *pushOptions) checkNoSchedule() error {
+	if !o.scheduleTime.IsZero() {
+		return ErrScheduleOption
+	}
+	return nil
+}
+
+// getPushOptions 合并单次推送的可选参数
+func getPushOptions(opts []PushOption) *pushOptions {
+	o := &pushOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// PushOptions 合并后的单次推送参数
+//
+//	由 ResolvePushOptions 返回，用于测试中检查传入的 PushOption，见 getuitest.Fake
+type PushOptions struct {
+	GroupName      string             //任务组名，WithGroupName
+	SafetyLimit    int64              //预估用户数上限，WithSafetyLimit
+	HasSafetyLimit bool               //是否设置了 SafetyLimit
+	DryRun         *DryRun            //演练模式的记录器，WithDryRun
+	Debug          bool               //是否输出调试信息，WithDebug
+	Ctx            context.Context    //请求使用的context，WithContext
+	ScheduleTime   time.Time          //定时推送的时间，WithScheduleTime
+	Truncate       *TruncatePolicy    //截断策略，WithTruncate
+	HasTruncate    bool               //是否设置了 Truncate，为true且Truncate为nil时不截断
+	TruncateReport *TruncateReport    //截断记录，WithTruncateReport
+	IOS            *models.IOSOptions //iOS通知的可选参数，WithIOS
+}
+
+// ResolvePushOptions 合并opts，返回设置的参数
+//
+//	不包含 AppConfig 中的默认值
+func ResolvePushOptions(opts ...PushOption) *PushOptions {
+	o := getPushOptions(opts)
+	return &PushOptions{
+		GroupName:      o.groupName,
+		SafetyLimit:    o.safetyLimit,
+		HasSafetyLimit: o.hasSafetyLimit,
+		DryRun:         o.dryRun,
+		Debug:          o.debug,
+		Ctx:            o.ctx,
+		ScheduleTime:   o.scheduleTime,
+		Truncate:       o.truncate,
+		HasTruncate:    o.hasTruncate,
+		TruncateReport: o.truncateReport,
+		IOS:            o.ios,
+	}
+}
+
+// CheckGroupName 校验任务组名
+//
+//	长度限制100字符，只允许填写数字、字母、横杠、下划线
+func CheckGroupName(groupName string) error {
+	return models.CheckGroupName(groupName)
+}
+
+// DailyGroupName 返回按天生成任务组名的方法
+//
+//	如 prefix_20220901，可用于 AppConfig.GroupNameFunc
+func DailyGroupName(prefix string) func() string {
+	return func() string {
+		return fmt.Sprintf("%s_%s", prefix, time.Now().Format("20060102"))
+	}
+}
+
+// MonthlyGroupName 返回按月生成任务组名的方法
+//
+//	如 prefix_202209，可用于 AppConfig.GroupNameFunc
+func MonthlyGroupName(prefix string) func() string {
+	return func() string {
+		return fmt.Sprintf("%s_%s", prefix, time.Now().Format("200601"))
+	}
+}
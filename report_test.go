@@ -0,0 +1,199 @@
+package getuipush_test
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	getuipush "github.com/zituocn/getui-push"
+	"github.com/zituocn/getui-push/getuitest"
+)
+
+// recordPaths 返回记录请求路径的中间件
+func recordPaths(paths *[]string) getuipush.Middleware {
+	return func(next getuipush.Doer) getuipush.Doer {
+		return getuipush.DoerFunc(func(req *getuipush.Request) (*getuipush.Response, error) {
+			*paths = append(*paths, req.Path)
+			return next.Do(req)
+		})
+	}
+}
+
+func TestReportPushTasks(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	var paths []string
+	client, err := s.NewClient(&getuipush.AppConfig{Middlewares: []getuipush.Middleware{recordPaths(&paths)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		taskIds  []string
+		requests int
+	}{
+		{name: "one", taskIds: taskIds(1), requests: 1},
+		{name: "limit", taskIds: taskIds(200), requests: 1},
+		{name: "above limit", taskIds: taskIds(201), requests: 2},
+		{name: "several chunks", taskIds: taskIds(450), requests: 3},
+		{name: "escaped", taskIds: []string{"task/1", "task 2", "task?3"}, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths = paths[:0]
+			if _, err := client.ReportPushTasks(tt.taskIds...); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(tt.taskIds))
+			requests := 0
+			for _, path := range paths {
+				i := strings.Index(path, "/report/push/task/")
+				if i < 0 {
+					continue
+				}
+				requests++
+				segment := path[i+len("/report/push/task/"):]
+				if strings.Contains(segment, "/") {
+					t.Fatalf("path %q is not escaped", path)
+				}
+				ids := strings.Split(segment, ",")
+				if len(ids) > 200 {
+					t.Fatalf("request has %d taskids, want at most 200", len(ids))
+				}
+				for _, id := range ids {
+					v, err := url.PathUnescape(id)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, v)
+				}
+			}
+			if requests != tt.requests {
+				t.Fatalf("requests = %d, want %d", requests, tt.requests)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.taskIds, "\n") {
+				t.Fatalf("taskids = %v, want %v", got, tt.taskIds)
+			}
+		})
+	}
+}
+
+func TestReportPushTasksError(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	var paths []string
+	client, err := s.NewClient(&getuipush.AppConfig{Middlewares: []getuipush.Middleware{recordPaths(&paths)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		taskIds []string
+	}{
+		{name: "none"},
+		{name: "empty", taskIds: []string{"task1", ""}},
+		{name: "blank", taskIds: []string{" "}},
+		{name: "comma", taskIds: []string{"task1,task2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.ReportPushTasks(tt.taskIds...); err == nil {
+				t.Fatalf("ReportPushTasks(%q) should fail", tt.taskIds)
+			}
+		})
+	}
+	if len(paths) != 0 {
+		t.Fatalf("invalid taskids sent requests: %v", paths)
+	}
+}
+
+func taskIds(n int) []string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = fmt.Sprintf("task-%d", i)
+	}
+	return list
+}
+
+func TestReportDateRange(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	day := time.Date(2024, 3, 1, 15, 30, 0, 0, shanghai)
+	tests := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		first   string
+		last    string
+		days    int
+		wantErr bool
+	}{
+		{name: "single day", start: day, end: day, first: "2024-03-01", last: "2024-03-01", days: 1},
+		{name: "same day different time", start: day, end: day.Add(8 * time.Hour).Add(-time.Minute), first: "2024-03-01", last: "2024-03-01", days: 1},
+		{name: "month boundary", start: day.AddDate(0, 0, -1), end: day, first: "2024-02-29", last: "2024-03-01", days: 2},
+		{name: "max span", start: day, end: day.AddDate(0, 0, 30), first: "2024-03-01", last: "2024-03-31", days: 31},
+		{name: "above max span", start: day, end: day.AddDate(0, 0, 31), wantErr: true},
+		{name: "end before start", start: day, end: day.AddDate(0, 0, -1), wantErr: true},
+		{name: "end in another zone", start: day, end: time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC), first: "2024-03-01", last: "2024-03-02", days: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, err := getuipush.ReportDateRange(tt.start, tt.end)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReportDateRange() = %v, want error", days)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(days) != tt.days {
+				t.Fatalf("len(days) = %d, want %d", len(days), tt.days)
+			}
+			if got := days[0].Format("2006-01-02"); got != tt.first {
+				t.Errorf("first = %s, want %s", got, tt.first)
+			}
+			if got := days[len(days)-1].Format("2006-01-02"); got != tt.last {
+				t.Errorf("last = %s, want %s", got, tt.last)
+			}
+			for _, d := range days {
+				if d.Location() != shanghai || d.Hour() != 0 {
+					t.Fatalf("day %v should be midnight in the start zone", d)
+				}
+			}
+		})
+	}
+}
+
+func TestReportDates(t *testing.T) {
+	s := getuitest.NewServer()
+	defer s.Close()
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := map[string]getuipush.PushService{"client": client, "fake": getuitest.NewFake()}
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+
+	for name, svc := range services {
+		t.Run(name, func(t *testing.T) {
+			pushes, err := svc.ReportPushDates(day, day)
+			if err != nil || len(pushes) != 1 || pushes["2024-03-01"] == nil {
+				t.Fatalf("ReportPushDates single day = %v, %v", pushes, err)
+			}
+			users, err := svc.ReportUserDates(day, day.AddDate(0, 0, 30))
+			if err != nil || len(users) != 31 {
+				t.Fatalf("ReportUserDates max span = %d days, %v", len(users), err)
+			}
+			if _, err = svc.ReportPushDates(day, day.AddDate(0, 0, -1)); err == nil {
+				t.Fatal("ReportPushDates with end before start should fail")
+			}
+			if _, err = svc.ReportUserDates(day, day.AddDate(0, 0, 31)); err == nil {
+				t.Fatal("ReportUserDates above max span should fail")
+			}
+		})
+	}
+}
@@ -2,14 +2,17 @@ package getuipush
 
 import (
 	"encoding/json"
+	"net/url"
+	"strings"
+
 	"github.com/zituocn/getui-push/models"
 )
 
 // searchTaskDetailByCid 可以查询某任务下某cid的具体实时推送路径情况
 //
 //	此接口需要SVIP权限，暂时不可用
-func searchTaskDetailByCid(appId, token string, cid, taskId string) (*models.TaskDetailResp, error) {
-	b, err := HttpRequest("GET", appId+"/task/detail/"+cid+"/"+taskId, token, nil)
+func searchTaskDetailByCid(t *transport, appId, token string, cid, taskId string) (*models.TaskDetailResp, error) {
+	b, err := t.httpRequest("GET", appId+"/task/detail/"+cid+"/"+taskId, token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -25,8 +28,9 @@ func searchTaskDetailByCid(appId, token string, cid, taskId string) (*models.Tas
 // searchSchedule 查询定时任务
 //
 //	该接口支持在推送完定时任务之后，查看定时任务状态，定时任务是否发送成功。
-func searchSchedule(appId, token, taskId string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/task/schedule/"+taskId, token, nil)
+func searchSchedule(t *transport, appId, token, taskId string) (*models.ScheduleTaskResp, error) {
+	resp := new(models.ScheduleTaskResp)
+	err := t.requestAPIData("GET", appId+"/task/schedule/"+taskId, token, nil, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +40,70 @@ func searchSchedule(appId, token, taskId string) (*models.Response, error) {
 // reportPushTask
 // 查询推送数据，可查询消息可下发数、下发数，接收数、展示数、点击数等结果。支持单个taskId查询和多个taskId查询。
 // 此接口调用，仅可以查询toList或toApp的推送结果数据；不能查询toSingle的推送结果数据。
-func reportPushTask(appId, token, taskId string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/report/push/task/"+taskId, token, nil)
+func reportPushTask(t *transport, appId, token, taskId string) (*models.Response, error) {
+	resp, err := t.requestAPI("GET", appId+"/report/push/task/"+taskId, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reportPushTasks 查询多个任务的推送数据
+//
+//	多个taskId使用英文逗号分隔，每个taskId单独转义
+func reportPushTasks(t *transport, appId, token string, taskIds []string) (*models.PushReportResp, error) {
+	list := make([]string, 0, len(taskIds))
+	for _, taskId := range taskIds {
+		list = append(list, url.PathEscape(taskId))
+	}
+	resp := new(models.PushReportResp)
+	err := t.requestAPIData("GET", appId+"/report/push/task/"+strings.Join(list, ","), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reportPushGroup 查询任务组的推送数据
+//
+//	任务组名即推送时的group_name
+func reportPushGroup(t *transport, appId, token, groupName string) (*models.PushReportResp, error) {
+	resp := new(models.PushReportResp)
+	err := t.requestAPIData("GET", appId+"/report/push/task_group/"+url.PathEscape(groupName), token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reportPushDate 查询单日推送数据
+//
+//	date 格式：yyyy-MM-dd
+func reportPushDate(t *transport, appId, token, date string) (*models.PushReportResp, error) {
+	resp := new(models.PushReportResp)
+	err := t.requestAPIData("GET", appId+"/report/push/date/"+date, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reportUserDate 查询单日用户数据
+//
+//	date 格式：yyyy-MM-dd
+func reportUserDate(t *transport, appId, token, date string) (*models.UserReportResp, error) {
+	resp := new(models.UserReportResp)
+	err := t.requestAPIData("GET", appId+"/report/user/date/"+date, token, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// reportOnlineUser 查询24小时在线用户数
+func reportOnlineUser(t *transport, appId, token string) (*models.OnlineUserResp, error) {
+	resp := new(models.OnlineUserResp)
+	err := t.requestAPIData("GET", appId+"/report/online_user", token, nil, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,79 @@
+package getuipush
+
+import (
+	"time"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+// Pusher 推送和任务管理
+type Pusher interface {
+	PushAll(msgType, scheduleTime int, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAllAt(msgType int, scheduleTime time.Time, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAllByClient(msgType, scheduleTime int, clientType ClientType, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAllByClients(msgType, scheduleTime int, clientTypes []ClientType, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAllByClientAt(msgType int, scheduleTime time.Time, clientType ClientType, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushSingleByCid(msgType int, cid string, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushSingleByAlias(msgType int, alias string, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushListByCid(msgType int, cid []string, payload *models.CustomMessage, opts ...PushOption) ([]*models.Response, error)
+	PushAllByCustomTag(msgType, scheduleTime int, customTag []string, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAllByCustomTagAt(msgType int, scheduleTime time.Time, customTag []string, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAllByLogicTags(msgType, scheduleTime int, tags []*models.Tag, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushAppByFastCustomTag(msgType, scheduleTime int, tag string, payload *models.CustomMessage, opts ...PushOption) (*models.Response, error)
+	PushMessage(msgType int, audience *models.Audience, message *models.Message, opts ...PushOption) (*models.Response, error)
+	PushListMessage(msgType int, cid []string, message *models.Message, opts ...PushOption) ([]*models.Response, error)
+	PushVoIP(msgType int, audience *models.Audience, message *models.Message, opts ...PushOption) (*models.Response, error)
+	PushSilent(audience *models.Audience, message *models.Message, opts ...PushOption) (*models.Response, error)
+
+	StopTask(taskId string) (*models.Response, error)
+	GetScheduleTask(taskId string) (*models.ScheduleTask, error)
+	GetScheduleTasks(taskIds ...string) (map[string]*models.ScheduleTask, error)
+	CancelScheduleTask(taskId string) (*models.Response, error)
+}
+
+// UserManager 别名、标签和用户查询
+type UserManager interface {
+	BindAlias(param *models.Alias) (*models.Response, error)
+	UnBindAlias(param *models.Alias) (*models.Response, error)
+	BindAliases(list []*models.Alias) (*models.AliasBatchResult, error)
+	UnBindAliases(list []*models.Alias) (*models.AliasBatchResult, error)
+	UnBindAllAlias(alias string) (*models.Response, error)
+
+	BindTags(cid string, param *models.CustomTagsParam) (*models.Response, error)
+	BindTagToCids(tag string, cid []string) (*models.TagBatchResult, error)
+	UnBindTagFromCids(tag string, cid []string) (*models.TagBatchResult, error)
+
+	SearchTags(cid string) (*models.Response, error)
+	SearchStatus(cid string) (*models.Response, error)
+	SearchUser(cid string) (*models.Response, error)
+	SearchAliasByCid(cid string) (*models.Response, error)
+	SearchCidByAlias(alias string) (*models.Response, error)
+
+	GetUserCount(tags []*models.Tag) (*models.Response, error)
+	PreviewAudience(tags []*models.Tag) (int64, error)
+}
+
+// Reporter 推送和用户统计
+type Reporter interface {
+	SearchTaskDetailByCid(cid, taskId string) (*models.TaskDetailResp, error)
+	ReportPushTask(taskId string) (*models.Response, error)
+	ReportPushTasks(taskIds ...string) (map[string]*models.PushReport, error)
+	ReportPushGroup(groupName string) (*models.PushReport, error)
+	ReportPushGroups(groupNames ...string) (map[string]*models.PushReport, error)
+	ReportPushDate(date time.Time) (*models.PushReport, error)
+	ReportPushDates(start, end time.Time) (map[string]*models.PushReport, error)
+	ReportUserDate(date time.Time) (*models.UserReport, error)
+	ReportUserDates(start, end time.Time) (map[string]*models.UserReport, error)
+	ReportOnlineUser() (map[string]int64, error)
+}
+
+// PushService 个推的所有接口
+//
+//	业务代码依赖此接口而不是 *PushClient，测试时可使用 getuitest.NewFake
+type PushService interface {
+	Pusher
+	UserManager
+	Reporter
+}
+
+var _ PushService = (*PushClient)(nil)
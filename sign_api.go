@@ -11,7 +11,7 @@ import (
 
 // getToken 获取个推token
 //	返回token和可能的错误
-func getToken(appId, appKey, masterSecret string) (token string, err error) {
+func getToken(t *transport, appId, appKey, masterSecret string) (token string, err error) {
 	sign, timestamp := signature(appKey, masterSecret)
 	param := &models.TokenParam{
 		Sign:      sign,
@@ -22,7 +22,7 @@ func getToken(appId, appKey, masterSecret string) (token string, err error) {
 	if err != nil {
 		return
 	}
-	b, err := HttpRequest("POST", appId+"/auth", "", bodyByte)
+	b, err := t.httpRequest("POST", appId+"/auth", "", bodyByte)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,300 @@
+package getuipush
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+/*
+标签表达式
+
+	custom_tag in (vip, gk2025) and not region in (11000000) and phone_type = android
+
+条件之间只支持 and (个推的多个标签之间是交集)，单个条件支持：
+
+	key in (a, b)      values之间是或的关系
+	key all (a, b)     values之间是与的关系
+	key = a            等于
+	key != a           不等于
+	key not in (a, b)  排除
+	not key in (a, b)  排除
+
+key 可以是 phone_type region custom_tag portrait
+含空格或特殊字符的value可以使用单引号或双引号
+*/
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF    tokenKind = iota
+	tokenWord             //key value 或关键字
+	tokenString           //引号中的value
+	tokenLParen           // (
+	tokenRParen           // )
+	tokenComma            // ,
+	tokenEq               // =
+	tokenNeq              // !=
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string
+	pos  int //在表达式中的位置(字符)，从1开始
+}
+
+// ParseTagExpr 解析标签表达式
+//
+//	返回的标签可用于 PushAllByLogicTags 和 GetUserCount
+//	语法错误时返回 *TagExprError
+func ParseTagExpr(expr string) ([]*models.Tag, error) {
+	tokens, err := lexTagExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &tagExprParser{tokens: tokens}
+	return p.parse()
+}
+
+// tagExprParser 标签表达式解析器
+type tagExprParser struct {
+	tokens []*token
+	i      int
+}
+
+func (p *tagExprParser) parse() ([]*models.Tag, error) {
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorf(p.peek(), "表达式为空")
+	}
+	tags := make([]*models.Tag, 0)
+	for {
+		tag, err := p.parseCond()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return tags, nil
+		case isKeyword(t, "and"):
+			continue
+		case isKeyword(t, "or"):
+			return nil, p.errorf(t, "条件之间只支持and，多个value之间的或请使用 in (...)")
+		default:
+			return nil, p.errorf(t, "期望and或表达式结束，实际为 %s", t.text)
+		}
+	}
+}
+
+// parseCond 解析单个条件
+func (p *tagExprParser) parseCond() (*models.Tag, error) {
+	not := false
+	if isKeyword(p.peek(), "not") {
+		p.next()
+		not = true
+	}
+	k := p.next()
+	if k.kind != tokenWord || isReserved(k) {
+		return nil, p.errorf(k, "期望标签key，实际为 %s", k.text)
+	}
+	key := strings.ToLower(k.text)
+	switch key {
+	case models.TagKeyPhoneType, models.TagKeyRegion, models.TagKeyCustomTag, models.TagKeyPortrait:
+	default:
+		return nil, p.errorf(k, "不支持的标签key: %s，可选 phone_type region custom_tag portrait", k.text)
+	}
+
+	tag := models.NewTag(key)
+	op := p.next()
+	switch {
+	case op.kind == tokenEq:
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		tag.Values = []string{v}
+	case op.kind == tokenNeq:
+		if not {
+			return nil, p.errorf(op, "not 不能与 != 同时使用")
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		tag.Values = []string{v}
+		tag.Not()
+	case isKeyword(op, "in"):
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		tag.Values = values
+	case isKeyword(op, "all"):
+		if not {
+			return nil, p.errorf(op, "not 不能与 all 同时使用")
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		tag.Values = values
+		tag.And()
+	case isKeyword(op, "not"):
+		if not {
+			return nil, p.errorf(op, "重复的not")
+		}
+		if in := p.next(); !isKeyword(in, "in") {
+			return nil, p.errorf(in, "not 后期望in，实际为 %s", in.text)
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		tag.Values = values
+		not = true
+	default:
+		return nil, p.errorf(op, "期望 in all = != 或 not in，实际为 %s", op.text)
+	}
+	if not {
+		tag.Not()
+	}
+	return tag, nil
+}
+
+// parseList 解析 (a, b, c)
+func (p *tagExprParser) parseList() ([]string, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, p.errorf(t, "期望 (，实际为 %s", t.text)
+	}
+	values := make([]string, 0)
+	if p.peek().kind == tokenRParen {
+		return nil, p.errorf(p.peek(), "values为空")
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		t := p.next()
+		switch t.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, p.errorf(t, "期望 , 或 )，实际为 %s", t.text)
+		}
+	}
+}
+
+// parseValue 解析单个value
+func (p *tagExprParser) parseValue() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		if t.text == "" {
+			return "", p.errorf(t, "value为空")
+		}
+		return t.text, nil
+	case tokenWord:
+		return t.text, nil
+	default:
+		return "", p.errorf(t, "期望value，实际为 %s", t.text)
+	}
+}
+
+func (p *tagExprParser) peek() *token {
+	return p.tokens[p.i]
+}
+
+func (p *tagExprParser) next() *token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *tagExprParser) errorf(t *token, format string, args ...interface{}) error {
+	return &TagExprError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lexTagExpr 把表达式拆分为词法单元
+func lexTagExpr(expr string) ([]*token, error) {
+	runes := []rune(expr)
+	tokens := make([]*token, 0)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, &token{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, &token{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, &token{kind: tokenComma, text: ",", pos: pos})
+			i++
+		case r == '=':
+			tokens = append(tokens, &token{kind: tokenEq, text: "=", pos: pos})
+			i++
+		case r == '!':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, &TagExprError{Pos: pos, Msg: "! 后期望 ="}
+			}
+			tokens = append(tokens, &token{kind: tokenNeq, text: "!=", pos: pos})
+			i += 2
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, &TagExprError{Pos: pos, Msg: "引号未闭合"}
+			}
+			tokens = append(tokens, &token{kind: tokenString, text: string(runes[i+1 : j]), pos: pos})
+			i = j + 1
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, &token{kind: tokenWord, text: string(runes[i:j]), pos: pos})
+			i = j
+		default:
+			return nil, &TagExprError{Pos: pos, Msg: fmt.Sprintf("无效的字符 %q", r)}
+		}
+	}
+	tokens = append(tokens, &token{kind: tokenEOF, text: "表达式结束", pos: len(runes) + 1})
+	return tokens, nil
+}
+
+// isWordRune 是否为key或value中的字符
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+// isKeyword 是否为某个关键字，不区分大小写
+func isKeyword(t *token, keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// isReserved 是否为保留的关键字
+func isReserved(t *token) bool {
+	for _, keyword := range []string{"and", "or", "not", "in", "all"} {
+		if isKeyword(t, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package getuipush
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestParseTagExpr(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []*models.Tag
+	}{
+		{
+			name: "in",
+			expr: "custom_tag in (vip, gk2025)",
+			want: []*models.Tag{models.TagCustom("vip", "gk2025")},
+		},
+		{
+			name: "all",
+			expr: "custom_tag all (vip, gk2025)",
+			want: []*models.Tag{models.TagCustom("vip", "gk2025").And()},
+		},
+		{
+			name: "eq",
+			expr: "phone_type = android",
+			want: []*models.Tag{models.TagPhoneType("android")},
+		},
+		{
+			name: "neq",
+			expr: "phone_type != ios",
+			want: []*models.Tag{models.TagPhoneType("ios").Not()},
+		},
+		{
+			name: "not in",
+			expr: "region not in (11000000, 12000000)",
+			want: []*models.Tag{models.TagRegion("11000000", "12000000").Not()},
+		},
+		{
+			name: "not binds to one condition",
+			expr: "not region in (11000000) and custom_tag in (vip)",
+			want: []*models.Tag{models.TagRegion("11000000").Not(), models.TagCustom("vip")},
+		},
+		{
+			name: "and chain",
+			expr: "custom_tag in (vip) and not region in (11000000) and phone_type = android",
+			want: []*models.Tag{models.TagCustom("vip"), models.TagRegion("11000000").Not(), models.TagPhoneType("android")},
+		},
+		{
+			name: "keywords are case insensitive",
+			expr: "CUSTOM_TAG IN (vip) AND NOT Region In (11000000)",
+			want: []*models.Tag{models.TagCustom("vip"), models.TagRegion("11000000").Not()},
+		},
+		{
+			name: "quoted values",
+			expr: `custom_tag in ("new user", 'a,b', 'and')`,
+			want: []*models.Tag{models.TagCustom("new user", "a,b", "and")},
+		},
+		{
+			name: "han values",
+			expr: "custom_tag = 会员",
+			want: []*models.Tag{models.TagCustom("会员")},
+		},
+		{
+			name: "whitespace",
+			expr: "  portrait in(a,b)\tand\nphone_type=ios  ",
+			want: []*models.Tag{models.TagPortrait("a", "b"), models.TagPhoneType("ios")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTagExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseTagExpr(%q) error: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseTagExpr(%q) = %s, want %s", tt.expr, tagsString(got), tagsString(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseTagExprError(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		pos  int
+		msg  string
+	}{
+		{name: "empty", expr: "  ", pos: 3, msg: "表达式为空"},
+		{name: "unclosed paren", expr: "custom_tag in (vip, gk2025", pos: 27, msg: "期望 , 或 )"},
+		{name: "trailing and", expr: "custom_tag in (vip) and", pos: 24, msg: "期望标签key"},
+		{name: "unknown key", expr: "city in (beijing)", pos: 1, msg: "不支持的标签key: city"},
+		{name: "empty in", expr: "custom_tag in ()", pos: 16, msg: "values为空"},
+		{name: "empty quoted value", expr: `custom_tag = ""`, pos: 14, msg: "value为空"},
+		{name: "or between conditions", expr: "custom_tag in (a) or region in (1)", pos: 19, msg: "只支持and"},
+		{name: "missing and", expr: "custom_tag in (a) region in (1)", pos: 19, msg: "期望and或表达式结束"},
+		{name: "missing operator", expr: "custom_tag (a)", pos: 12, msg: "期望 in all = != 或 not in"},
+		{name: "missing paren", expr: "custom_tag in a", pos: 15, msg: "期望 ("},
+		{name: "keyword as key", expr: "and in (a)", pos: 1, msg: "期望标签key"},
+		{name: "not with neq", expr: "not phone_type != ios", pos: 16, msg: "not 不能与 != 同时使用"},
+		{name: "not with all", expr: "not custom_tag all (a)", pos: 16, msg: "not 不能与 all 同时使用"},
+		{name: "double not", expr: "not region not in (1)", pos: 12, msg: "重复的not"},
+		{name: "not without in", expr: "region not (1)", pos: 12, msg: "not 后期望in"},
+		{name: "unclosed quote", expr: `custom_tag = "vip`, pos: 14, msg: "引号未闭合"},
+		{name: "bang without eq", expr: "phone_type ! ios", pos: 12, msg: "! 后期望 ="},
+		{name: "invalid rune", expr: "custom_tag = vip;", pos: 17, msg: "无效的字符"},
+		{name: "position counts runes", expr: "custom_tag = 会员 x", pos: 17, msg: "期望and或表达式结束"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := ParseTagExpr(tt.expr)
+			if err == nil {
+				t.Fatalf("ParseTagExpr(%q) = %s, want error", tt.expr, tagsString(tags))
+			}
+			var exprErr *TagExprError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("ParseTagExpr(%q) error type %T, want *TagExprError", tt.expr, err)
+			}
+			if exprErr.Pos != tt.pos {
+				t.Errorf("ParseTagExpr(%q) pos = %d, want %d (%v)", tt.expr, exprErr.Pos, tt.pos, err)
+			}
+			if !strings.Contains(exprErr.Msg, tt.msg) {
+				t.Errorf("ParseTagExpr(%q) msg = %q, want containing %q", tt.expr, exprErr.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+func tagsString(tags []*models.Tag) string {
+	list := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		list = append(list, tag.Key+" "+tag.OptType+" "+strings.Join(tag.Values, ","))
+	}
+	return "[" + strings.Join(list, "; ") + "]"
+}
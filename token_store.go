@@ -0,0 +1,68 @@
+package getuipush
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zituocn/gow/lib/goredis"
+)
+
+// TokenStore token的存储
+//
+//	默认使用redis，见 PushStore；测试或单实例部署时可使用 NewMemoryTokenStore
+type TokenStore interface {
+	Get(key string) (string, error)
+	Set(key, token string, expiration time.Duration) error
+}
+
+// redisTokenStore 使用 goredis 默认连接存储token
+type redisTokenStore struct{}
+
+func (r *redisTokenStore) Get(key string) (string, error) {
+	return goredis.GetRDB().Get(ctx, key).Result()
+}
+
+func (r *redisTokenStore) Set(key, token string, expiration time.Duration) error {
+	_, err := goredis.GetRDB().SetEx(ctx, key, token, expiration).Result()
+	return err
+}
+
+// memoryTokenStore 在内存中存储token
+type memoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*memoryToken
+}
+
+type memoryToken struct {
+	token    string
+	expireAt time.Time
+}
+
+// NewMemoryTokenStore 返回在内存中存储token的TokenStore
+//
+//	多个实例之间不共享token
+func NewMemoryTokenStore() TokenStore {
+	return &memoryTokenStore{
+		tokens: make(map[string]*memoryToken),
+	}
+}
+
+func (m *memoryTokenStore) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tokens[key]
+	if !ok || time.Now().After(t.expireAt) {
+		return "", nil
+	}
+	return t.token, nil
+}
+
+func (m *memoryTokenStore) Set(key, token string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[key] = &memoryToken{
+		token:    token,
+		expireAt: time.Now().Add(expiration),
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package getuipush
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+// 通道的名称，用作 TruncatePolicy.Limits 和 TruncateReport 的key
+//
+//	厂商通道与 Setting.Strategy 的key一致；VendorGetui 是个推自己的通道，对应 notification，
+//	不是 Strategy 的key(Strategy 中个推通道为 "default")
+const (
+	VendorGetui   = "gt"    //个推通道
+	VendorHuawei  = "hw"    //华为
+	VendorHonor   = "ho"    //荣耀
+	VendorXiaomi  = "xm"    //小米
+	VendorVivo    = "vv"    //vivo
+	VendorOppo    = "op"    //oppo
+	VendorIOS     = "ios"   //苹果APNs
+	VendorHarmony = "hoshw" //鸿蒙华为
+)
+
+// defaultEllipsis 截断后追加的省略号
+const defaultEllipsis = "..."
+
+// TextLimit 通知标题和内容的最大长度(字符)，0表示不限制
+type TextLimit struct {
+	Title int
+	Body  int
+}
+
+// defaultTextLimits 各厂商通知标题和内容的最大长度
+//
+//	个推通道取自个推REST API v2文档中 notification 的限制：标题50、内容256
+//	厂商通道取自个推文档的厂商通道限制说明和各厂商推送服务文档：小米50/128、vivo 40/100、oppo 50/200，华为、荣耀、鸿蒙40/256
+//	APNs只限制payload不超过4KB，iOS的100/1024是为控制payload大小设置的值
+//	android的厂商通知是同一份，按所有android厂商中最小的值截断
+var defaultTextLimits = map[string]TextLimit{
+	VendorGetui:   {Title: 50, Body: 256},
+	VendorHuawei:  {Title: 40, Body: 256},
+	VendorHonor:   {Title: 40, Body: 256},
+	VendorXiaomi:  {Title: 50, Body: 128},
+	VendorVivo:    {Title: 40, Body: 100},
+	VendorOppo:    {Title: 50, Body: 200},
+	VendorIOS:     {Title: 100, Body: 1024},
+	VendorHarmony: {Title: 40, Body: 256},
+}
+
+// DefaultTextLimits 返回各厂商默认的最大长度
+//
+//	返回的是副本，修改不影响默认值；需要调整时设置到 TruncatePolicy.Limits
+func DefaultTextLimits() map[string]TextLimit {
+	limits := make(map[string]TextLimit, len(defaultTextLimits))
+	for vendor, l := range defaultTextLimits {
+		limits[vendor] = l
+	}
+	return limits
+}
+
+// androidVendors 共用 ups.notification 的android厂商
+var androidVendors = []string{VendorGetui, VendorHuawei, VendorHonor, VendorXiaomi, VendorVivo, VendorOppo}
+
+// TruncatePolicy 通知标题和内容的截断策略
+//
+//	按字符截断，不会截断半个汉字，截断后以 Ellipsis 结尾
+//	设置到 AppConfig.Truncate 或使用 WithTruncate 后生效，默认不截断
+//	WithTruncate(nil) 可在单次推送中关闭 AppConfig.Truncate
+type TruncatePolicy struct {
+	Limits   map[string]TextLimit //各厂商的最大长度，为空的厂商使用 DefaultTextLimits
+	Ellipsis string               //省略号，为空时使用 "..."
+}
+
+// Truncation 一次截断
+type Truncation struct {
+	Field    string `json:"field"`    //字段，如 push_channel.ios.aps.alert.title
+	Vendor   string `json:"vendor"`   //按此厂商的限制截断
+	Limit    int    `json:"limit"`    //最大长度(字符)
+	Original string `json:"original"` //原内容
+	Result   string `json:"result"`   //截断后的内容
+}
+
+// TruncateReport 记录截断了哪些内容
+//
+//	使用 WithTruncateReport 传入，可在多次推送间复用
+type TruncateReport struct {
+	mu    sync.Mutex
+	items []*Truncation
+}
+
+// NewTruncateReport 返回截断记录
+func NewTruncateReport() *TruncateReport {
+	return &TruncateReport{
+		items: make([]*Truncation, 0),
+	}
+}
+
+// Items 返回所有截断记录
+func (r *TruncateReport) Items() []*Truncation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*Truncation, len(r.items))
+	copy(list, r.items)
+	return list
+}
+
+// Reset 清空截断记录
+func (r *TruncateReport) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = make([]*Truncation, 0)
+}
+
+// add 添加截断记录
+func (r *TruncateReport) add(items []*Truncation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, items...)
+}
+
+// TruncateText 把s截断为不超过max个字符
+//
+//	超过时以 ellipsis 结尾，返回截断后的内容和是否截断；max为0时不截断
+func TruncateText(s string, max int, ellipsis string) (string, bool) {
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s, false
+	}
+	n := max - utf8.RuneCountInString(ellipsis)
+	if n <= 0 {
+		return string([]rune(s)[:max]), true
+	}
+	return string([]rune(s)[:n]) + ellipsis, true
+}
+
+// limit 返回厂商的最大长度
+func (p *TruncatePolicy) limit(vendor string) TextLimit {
+	if l, ok := p.Limits[vendor]; ok {
+		return l
+	}
+	return defaultTextLimits[vendor]
+}
+
+// androidLimit 返回android厂商中最小的长度，及对应的厂商
+func (p *TruncatePolicy) androidLimit() (limit TextLimit, titleVendor, bodyVendor string) {
+	for _, vendor := range androidVendors {
+		l := p.limit(vendor)
+		if l.Title > 0 && (limit.Title == 0 || l.Title < limit.Title) {
+			limit.Title, titleVendor = l.Title, vendor
+		}
+		if l.Body > 0 && (limit.Body == 0 || l.Body < limit.Body) {
+			limit.Body, bodyVendor = l.Body, vendor
+		}
+	}
+	return
+}
+
+// apply 截断厂商通道中的通知标题和内容
+//
+//	只修改 pushChannel，返回截断记录
+func (p *TruncatePolicy) apply(pushChannel *models.PushChannel) []*Truncation {
+	if p == nil || pushChannel == nil {
+		return nil
+	}
+	ellipsis := p.Ellipsis
+	if ellipsis == "" {
+		ellipsis = defaultEllipsis
+	}
+	items := make([]*Truncation, 0)
+	cut := func(field, vendor string, max int, s *string) {
+		result, ok := TruncateText(*s, max, ellipsis)
+		if !ok {
+			return
+		}
+		items = append(items, &Truncation{Field: field, Vendor: vendor, Limit: max, Original: *s, Result: result})
+		*s = result
+	}
+
+	if pushChannel.Android != nil && pushChannel.Android.Ups.Notification != nil {
+		n := pushChannel.Android.Ups.Notification
+		l, titleVendor, bodyVendor := p.androidLimit()
+		cut("push_channel.android.ups.notification.title", titleVendor, l.Title, &n.Title)
+		cut("push_channel.android.ups.notification.body", bodyVendor, l.Body, &n.Body)
+	}
+	if pushChannel.IOS != nil && pushChannel.IOS.Aps != nil && pushChannel.IOS.Aps.Alert != nil {
+		alert := pushChannel.IOS.Aps.Alert
+		l := p.limit(VendorIOS)
+		cut("push_channel.ios.aps.alert.title", VendorIOS, l.Title, &alert.Title)
+		cut("push_channel.ios.aps.alert.body", VendorIOS, l.Body, &alert.Body)
+	}
+	if pushChannel.Harmony != nil && pushChannel.Harmony.Notification != nil {
+		n := pushChannel.Harmony.Notification
+		l := p.limit(VendorHarmony)
+		cut("push_channel.harmony.notification.title", VendorHarmony, l.Title, &n.Title)
+		cut("push_channel.harmony.notification.body", VendorHarmony, l.Body, &n.Body)
+	}
+	return items
+}
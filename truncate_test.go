@@ -0,0 +1,122 @@
+package getuipush
+
+import (
+	"testing"
+
+	"github.com/zituocn/getui-push/models"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		max      int
+		ellipsis string
+		want     string
+		cut      bool
+	}{
+		{name: "max zero", s: "abcdef", max: 0, ellipsis: "...", want: "abcdef"},
+		{name: "negative max", s: "abcdef", max: -1, ellipsis: "...", want: "abcdef"},
+		{name: "within max", s: "abcdef", max: 6, ellipsis: "...", want: "abcdef"},
+		{name: "ellipsis", s: "abcdef", max: 5, ellipsis: "...", want: "ab...", cut: true},
+		{name: "empty ellipsis", s: "abcdef", max: 3, ellipsis: "", want: "abc", cut: true},
+		{name: "max equals ellipsis", s: "abcdef", max: 3, ellipsis: "...", want: "abc", cut: true},
+		{name: "max less than ellipsis", s: "abcdef", max: 2, ellipsis: "...", want: "ab", cut: true},
+		{name: "han within max", s: "会员专享活动", max: 6, ellipsis: "…", want: "会员专享活动"},
+		{name: "han at boundary", s: "会员专享活动", max: 5, ellipsis: "…", want: "会员专享…", cut: true},
+		{name: "han with ascii ellipsis", s: "会员专享活动", max: 4, ellipsis: "...", want: "会...", cut: true},
+		{name: "han max less than ellipsis", s: "会员专享活动", max: 1, ellipsis: "...", want: "会", cut: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cut := TruncateText(tt.s, tt.max, tt.ellipsis)
+			if got != tt.want || cut != tt.cut {
+				t.Fatalf("TruncateText(%q, %d, %q) = %q, %v, want %q, %v", tt.s, tt.max, tt.ellipsis, got, cut, tt.want, tt.cut)
+			}
+		})
+	}
+}
+
+func TestAndroidLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      map[string]TextLimit
+		want        TextLimit
+		titleVendor string
+		bodyVendor  string
+	}{
+		{
+			name:        "defaults",
+			want:        TextLimit{Title: 40, Body: 100},
+			titleVendor: VendorHuawei,
+			bodyVendor:  VendorVivo,
+		},
+		{
+			name:        "override one vendor",
+			limits:      map[string]TextLimit{VendorGetui: {Title: 10, Body: 20}},
+			want:        TextLimit{Title: 10, Body: 20},
+			titleVendor: VendorGetui,
+			bodyVendor:  VendorGetui,
+		},
+		{
+			name:        "zero means unlimited",
+			limits:      map[string]TextLimit{VendorVivo: {Title: 20}},
+			want:        TextLimit{Title: 20, Body: 128},
+			titleVendor: VendorVivo,
+			bodyVendor:  VendorXiaomi,
+		},
+		{
+			name: "all unlimited",
+			limits: map[string]TextLimit{
+				VendorGetui: {}, VendorHuawei: {}, VendorHonor: {},
+				VendorXiaomi: {}, VendorVivo: {}, VendorOppo: {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &TruncatePolicy{Limits: tt.limits}
+			got, titleVendor, bodyVendor := p.androidLimit()
+			if got != tt.want || titleVendor != tt.titleVendor || bodyVendor != tt.bodyVendor {
+				t.Fatalf("androidLimit() = %+v, %q, %q, want %+v, %q, %q", got, titleVendor, bodyVendor, tt.want, tt.titleVendor, tt.bodyVendor)
+			}
+		})
+	}
+}
+
+func TestDefaultTextLimitsCopy(t *testing.T) {
+	limits := DefaultTextLimits()
+	limits[VendorIOS] = TextLimit{Title: 1, Body: 1}
+	if got := DefaultTextLimits()[VendorIOS]; got == limits[VendorIOS] {
+		t.Fatalf("DefaultTextLimits() should return a copy, got %+v", got)
+	}
+	if got := (&TruncatePolicy{}).limit(VendorIOS); got.Title != 100 {
+		t.Fatalf("limit(ios) = %+v, want title 100", got)
+	}
+}
+
+func TestTruncateOption(t *testing.T) {
+	g := &PushClient{AppConfig: &AppConfig{
+		Truncate: &TruncatePolicy{Limits: map[string]TextLimit{VendorIOS: {Title: 3}}, Ellipsis: "…"},
+	}}
+	tests := []struct {
+		name string
+		opts []PushOption
+		want string
+	}{
+		{name: "app config", want: "ab…"},
+		{name: "override", opts: []PushOption{WithTruncate(&TruncatePolicy{Limits: map[string]TextLimit{VendorIOS: {Title: 4}}})}, want: "a..."},
+		{name: "disable", opts: []PushOption{WithTruncate(nil)}, want: "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pushChannel := &models.PushChannel{
+				IOS: &models.IOSChannel{Aps: &models.IOSAps{Alert: &models.IOSAlert{Title: "abcdef"}}},
+			}
+			g.truncate(getPushOptions(tt.opts), pushChannel)
+			if got := pushChannel.IOS.Aps.Alert.Title; got != tt.want {
+				t.Fatalf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
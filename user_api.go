@@ -1,15 +1,19 @@
 package getuipush
 
-import "github.com/zituocn/getui-push/models"
+import (
+	"net/url"
+
+	"github.com/zituocn/getui-push/models"
+)
 
 // bindAlias 绑定别名
 // @https://docs.getui.com/getui/server/rest_v2/user/
-func bindAlias(appId, token string, param *models.AliasParam) (*models.Response, error) {
+func bindAlias(t *transport, appId, token string, param *models.AliasParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/user/alias", token, bodyByte)
+	resp, err := t.requestAPI("POST", appId+"/user/alias", token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -20,12 +24,12 @@ func bindAlias(appId, token string, param *models.AliasParam) (*models.Response,
 // unBindAlias 解绑别名
 //
 //	cid与alias成对出现
-func unBindAlias(appId, token string, param *models.AliasParam) (*models.Response, error) {
+func unBindAlias(t *transport, appId, token string, param *models.AliasParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("DELETE", appId+"/user/alias", token, bodyByte)
+	resp, err := t.requestAPI("DELETE", appId+"/user/alias", token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -33,8 +37,8 @@ func unBindAlias(appId, token string, param *models.AliasParam) (*models.Respons
 }
 
 // unBindAllAlias 解绑所有与该别名绑定的cid
-func unBindAllAlias(appId, token, alias string) (*models.Response, error) {
-	resp, err := RequestAPI("DELETE", appId+"/user/alias/"+alias, token, nil)
+func unBindAllAlias(t *transport, appId, token, alias string) (*models.Response, error) {
+	resp, err := t.requestAPI("DELETE", appId+"/user/alias/"+url.PathEscape(alias), token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -44,12 +48,42 @@ func unBindAllAlias(appId, token, alias string) (*models.Response, error) {
 // bindTags 给一个cid，绑定多个标签
 //
 //	此接口对单个cid有频控限制，每天只能修改一次，最多设置100个标签；单个标签长度最大为32字符，标签总长度最大为512个字符
-func bindTags(appId, token, cid string, param *models.CustomTagsParam) (*models.Response, error) {
+func bindTags(t *transport, appId, token, cid string, param *models.CustomTagsParam) (*models.Response, error) {
+	bodyByte, err := makeReqBody(param)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.requestAPI("POST", appId+"/user/custom_tag/cid/"+cid, token, bodyByte)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// bindTagBatch 一批用户绑定一个标签
+//
+//	cid数组长度不大于1000
+func bindTagBatch(t *transport, appId, token, tag string, param *models.TagCidParam) (*models.Response, error) {
+	bodyByte, err := makeReqBody(param)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.requestAPI("PUT", appId+"/user/custom_tag/batch/"+url.PathEscape(tag), token, bodyByte)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// unBindTagBatch 一批用户解绑一个标签
+//
+//	cid数组长度不大于1000
+func unBindTagBatch(t *transport, appId, token, tag string, param *models.TagCidParam) (*models.Response, error) {
 	bodyByte, err := makeReqBody(param)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := RequestAPI("POST", appId+"/user/custom_tag/cid/"+cid, token, bodyByte)
+	resp, err := t.requestAPI("DELETE", appId+"/user/custom_tag/batch/"+url.PathEscape(tag), token, bodyByte)
 	if err != nil {
 		return nil, err
 	}
@@ -59,8 +93,8 @@ func bindTags(appId, token, cid string, param *models.CustomTagsParam) (*models.
 // searchTags 查询某个用户已绑定的标签
 //
 //	可用于运营后台查询
-func searchTags(appId, token, cid string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/user/custom_tag/cid/"+cid, token, nil)
+func searchTags(t *transport, appId, token, cid string) (*models.Response, error) {
+	resp, err := t.requestAPI("GET", appId+"/user/custom_tag/cid/"+cid, token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -70,8 +104,8 @@ func searchTags(appId, token, cid string) (*models.Response, error) {
 // searchStatus 查询某个用户的状态，是否在线，上次在线时间等
 //
 //	根据cid查询
-func searchStatus(appId, token, cid string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/user/status/"+cid, token, nil)
+func searchStatus(t *transport, appId, token, cid string) (*models.Response, error) {
+	resp, err := t.requestAPI("GET", appId+"/user/status/"+cid, token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -82,8 +116,8 @@ func searchStatus(appId, token, cid string) (*models.Response, error) {
 // searchUser 查询用户信息
 //
 //	根据cid查询
-func searchUser(appId, token, cid string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/user/detail/"+cid, token, nil)
+func searchUser(t *transport, appId, token, cid string) (*models.Response, error) {
+	resp, err := t.requestAPI("GET", appId+"/user/detail/"+cid, token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +127,8 @@ func searchUser(appId, token, cid string) (*models.Response, error) {
 // searchAliasByCid 按cid查询别名
 //
 //	即这台设备上登录过哪些帐号
-func searchAliasByCid(appId, token, cid string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/user/alias/cid/"+cid, token, nil)
+func searchAliasByCid(t *transport, appId, token, cid string) (*models.Response, error) {
+	resp, err := t.requestAPI("GET", appId+"/user/alias/cid/"+cid, token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -104,8 +138,8 @@ func searchAliasByCid(appId, token, cid string) (*models.Response, error) {
 // searchCidByAlias 按alias查cid
 //
 //	即这个alias绑定过哪些设备
-func searchCidByAlias(appId, token, alias string) (*models.Response, error) {
-	resp, err := RequestAPI("GET", appId+"/user/cid/alias/"+alias, token, nil)
+func searchCidByAlias(t *transport, appId, token, alias string) (*models.Response, error) {
+	resp, err := t.requestAPI("GET", appId+"/user/cid/alias/"+alias, token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -113,13 +147,12 @@ func searchCidByAlias(appId, token, alias string) (*models.Response, error) {
 }
 
 // getUserCount 获取用户总量
-func getUserCount(appId, token string, Tag []*models.Tag) (*models.Response, error) {
-	pushTag := struct {
-		Tag []*models.Tag `json:"tag"`
-	}{}
-	pushTag.Tag = Tag
-	bodyByte, err := makeReqBody(pushTag)
-	resp, err := RequestAPI("POST", appId+"/user/count", token, bodyByte)
+func getUserCount(t *transport, appId, token string, Tag []*models.Tag) (*models.Response, error) {
+	bodyByte, err := makeReqBody(models.ByTags(Tag...))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.requestAPI("POST", appId+"/user/count", token, bodyByte)
 	if err != nil {
 		return nil, err
 	}